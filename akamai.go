@@ -16,7 +16,8 @@
 package main
 
 import (
-	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -31,7 +32,6 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
-	"text/template"
 	"time"
 
 	"github.com/briandowns/spinner"
@@ -44,25 +44,66 @@ import (
 
 const (
 	VERSION = "0.4.3"
+
+	upgradeAvailableExitCode = 2
 )
 
 func main() {
+	setupParallelWorker()
 	setCliTemplates()
 
 	os.Setenv("AKAMAI_CLI", "1")
 	exportConfigEnv()
 
+	if isUpgradeCheckWorker() {
+		runUpgradeCheck()
+		return
+	}
+
 	app := cli.NewApp()
 	app.Name = "akamai"
 	app.Usage = "Akamai CLI"
 	app.Version = VERSION
 	app.Copyright = "Copyright (C) Akamai Technologies, Inc"
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:   "profile",
+			Usage:  "Use the settings and credentials of the named `PROFILE`",
+			EnvVar: profileEnv,
+		},
+		cli.StringFlag{
+			Name:  "edgerc",
+			Usage: "Use the credentials in the .edgerc file at `PATH`",
+		},
+		cli.StringFlag{
+			Name:  "section",
+			Usage: "Use the credentials in the `SECTION` of the .edgerc file",
+		},
+		cli.StringFlag{
+			Name:  "accountkey",
+			Usage: "Use the account switch `KEY` to act on another account",
+		},
+	}
+	app.Before = func(c *cli.Context) error {
+		if err := setProfile(c.String("profile")); err != nil {
+			cli.HandleExitCoder(cli.NewExitError(color.RedString(err.Error()), 1))
+		}
+		// "edgerc add" creates the file and section, so they don't need to exist yet
+		isEdgercAdd := c.Args().Get(0) == "edgerc" && c.Args().Get(1) == "add"
+		if err := setCredentials(c.String("edgerc"), c.String("section"), c.String("accountkey"), !isEdgercAdd); err != nil {
+			cli.HandleExitCoder(cli.NewExitError(color.RedString(err.Error()), 1))
+		}
+		return nil
+	}
 
-	firstRun()
+	// Parallel workers are run by another akamai process, which has already done this
+	if !isParallelWorker() {
+		firstRun()
 
-	if latestVersion := checkForUpgrade(false); latestVersion != "" {
-		if upgradeCli(latestVersion) {
-			return
+		if latestVersion := checkForUpgrade(false); latestVersion != "" {
+			if upgradeCli(latestVersion) {
+				return
+			}
 		}
 	}
 
@@ -143,81 +184,179 @@ func cmdList(c *cli.Context) {
 }
 
 func cmdInstall(c *cli.Context) error {
+	if c.Bool("from-lock") {
+		return installFromLockfile(c.Args().First(), c.Bool("force"))
+	}
+
 	if !c.Args().Present() {
-		return cli.NewExitError(color.RedString("You must specify a repository URL"), 1)
+		if c.Bool("link") {
+			return cli.NewExitError(color.RedString("You must specify a directory"), 1)
+		}
+
+		return installFromProject(c.Bool("force"))
 	}
 
 	oldCmds := getCommands()
 
+	if c.Bool("link") {
+		for _, dir := range c.Args() {
+			if err := linkPackage(dir, c.Bool("force")); err != nil {
+				return err
+			}
+		}
+
+		listDiff(oldCmds)
+		return nil
+	}
+
+	if jobs := getJobs(c); jobs > 1 && len(c.Args()) > 1 {
+		args := []string{"install"}
+		if c.Bool("force") || confirmParallelBinaryFallback() {
+			args = append(args, "--force")
+		}
+
+		err := runParallel(jobs, args, c.Args())
+		listDiff(oldCmds)
+		updateLockfile()
+
+		return err
+	}
+
 	for _, repo := range c.Args() {
-		srcPath, err := getAkamaiCliSrcPath()
-		if err != nil {
+		repo, ref := splitRepoRef(repo)
+		if _, err := installRepo(githubize(repo), ref, getBinaryInstall(c.Bool("force"))); err != nil {
 			return err
 		}
+	}
+
+	if !isParallelWorker() {
+		listDiff(oldCmds)
+	}
+	updateLockfile()
 
-		_ = os.MkdirAll(srcPath, 0775)
+	return nil
+}
+
+func installRepo(repo string, ref string, binary binaryInstall) (string, error) {
+	srcPath, err := getAkamaiCliSrcPath()
+	if err != nil {
+		return "", err
+	}
 
-		repo = githubize(repo)
+	_ = os.MkdirAll(srcPath, 0775)
 
+	if ref != "" {
+		fmt.Printf("Attempting to fetch command from %s (%s)...", repo, ref)
+	} else {
 		fmt.Printf("Attempting to fetch command from %s...", repo)
+	}
 
-		dirName := strings.TrimSuffix(filepath.Base(repo), ".git")
-		packageDir := filepath.Join(srcPath, dirName)
-		if _, err := os.Stat(packageDir); err == nil {
-			fmt.Println("... [" + color.RedString("FAIL") + "]")
-			return cli.NewExitError(color.RedString("Package directory already exists (%s)", packageDir), 1)
-		}
+	dirName := strings.TrimSuffix(filepath.Base(repo), ".git")
+	packageDir := filepath.Join(srcPath, dirName)
+	if _, err := os.Stat(packageDir); err == nil {
+		fmt.Println("... [" + color.RedString("FAIL") + "]")
+		return "", cli.NewExitError(color.RedString("Package directory already exists (%s)", packageDir), 1)
+	}
 
-		_, err = git.PlainClone(packageDir, false, &git.CloneOptions{
-			URL:      repo,
-			Progress: nil,
-		})
+	stagingDir, err := newStagingDir(dirName)
+	if err != nil {
+		fmt.Println("... [" + color.RedString("FAIL") + "]")
+		return "", cli.NewExitError(color.RedString("Unable to create staging directory: "+err.Error()), 1)
+	}
+
+	gitRepo, err := git.PlainClone(stagingDir, false, &git.CloneOptions{
+		URL:      repo,
+		Progress: nil,
+	})
+
+	if err != nil {
+		fmt.Println("... [" + color.RedString("FAIL") + "]")
+		discardStagingDir(stagingDir)
+		return "", cli.NewExitError(color.RedString("Unable to clone repository: "+err.Error()), 1)
+	}
+
+	if ref != "" {
+		hash, err := resolveRef(gitRepo, ref)
+		if err == nil {
+			err = checkoutHash(gitRepo, hash)
+		}
 
 		if err != nil {
 			fmt.Println("... [" + color.RedString("FAIL") + "]")
-			os.RemoveAll(packageDir)
-			return cli.NewExitError(color.RedString("Unable to clone repository: "+err.Error()), 1)
+			discardStagingDir(stagingDir)
+			return "", cli.NewExitError(color.RedString("Unable to checkout \"%s\": %s", ref, err.Error()), 1)
 		}
+	}
 
-		fmt.Println("... [" + color.GreenString("OK") + "]")
+	fmt.Println("... [" + color.GreenString("OK") + "]")
 
-		if !installPackage(packageDir, c.Bool("force")) {
-			os.RemoveAll(packageDir)
-			return cli.NewExitError("", 1)
-		}
+	if !installPackage(stagingDir, binary) {
+		discardStagingDir(stagingDir)
+		removePackageState(packageDir)
+		return "", cli.NewExitError("", 1)
 	}
 
-	listDiff(oldCmds)
+	if err := commitStagingDir(stagingDir, packageDir); err != nil {
+		discardStagingDir(stagingDir)
+		removePackageState(packageDir)
+		return "", cli.NewExitError(color.RedString("Unable to install package: "+err.Error()), 1)
+	}
 
-	return nil
+	state := getPackageState(packageDir)
+	state.Repo = repo
+	state.Ref = ref
+	setPackageState(packageDir, state)
+
+	return packageDir, nil
 }
 
 func cmdUpdate(c *cli.Context) error {
+	if strategy := c.String("strategy"); strategy != "" && strategy != updateStrategyHead && strategy != updateStrategyTag {
+		return cli.NewExitError(color.RedString("Unknown update strategy \"%s\", must be one of: %s, %s", strategy, updateStrategyHead, updateStrategyTag), 1)
+	}
+
+	cmds := []string(c.Args())
 	if !c.Args().Present() {
 		var builtinCmds map[string]bool = make(map[string]bool)
 		for _, cmd := range getBuiltinCommands() {
 			builtinCmds[strings.ToLower(cmd.Commands[0].Name)] = true
 		}
 
+		// Only one command per package is needed to update it
 		for _, cmd := range getCommands() {
-			for _, command := range cmd.Commands {
-				if _, ok := builtinCmds[command.Name]; !ok {
-					if err := updatePackage(command.Name, c.Bool("force")); err != nil {
-						return err
-					}
-				}
+			if len(cmd.Commands) == 0 {
+				continue
+			}
+
+			if _, ok := builtinCmds[cmd.Commands[0].Name]; !ok {
+				cmds = append(cmds, cmd.Commands[0].Name)
 			}
 		}
+	}
 
-		return nil
+	if jobs := getJobs(c); jobs > 1 && len(cmds) > 1 {
+		args := []string{"update"}
+		if c.Bool("force") || confirmParallelBinaryFallback() {
+			args = append(args, "--force")
+		}
+		if c.String("strategy") != "" {
+			args = append(args, "--strategy", c.String("strategy"))
+		}
+
+		err := runParallel(jobs, args, cmds)
+		updateLockfile()
+
+		return err
 	}
 
-	for _, cmd := range c.Args() {
-		if err := updatePackage(cmd, c.Bool("force")); err != nil {
+	for _, cmd := range cmds {
+		if err := updatePackage(cmd, c.Bool("force"), c.String("strategy")); err != nil {
 			return err
 		}
 	}
 
+	updateLockfile()
+
 	return nil
 }
 
@@ -244,19 +383,92 @@ func cmdUninstall(c *cli.Context) error {
 			return cli.NewExitError(color.RedString("unable to uninstall, was it installed using "+color.CyanString("\"akamai install\"")+"?"), 1)
 		}
 
+		if getPackageState(repoDir).Link == repoDir {
+			removePackageState(repoDir)
+			status.FinalMSG = fmt.Sprintf("Attempting to uninstall \"%s\" command...", cmd) + "... [" + color.GreenString("OK") + "]\n"
+			status.Stop()
+			color.Cyan("Linked package unregistered, %s has not been removed", repoDir)
+			continue
+		}
+
 		if err := os.RemoveAll(repoDir); err != nil {
 			status.FinalMSG = fmt.Sprintf("Attempting to uninstall \"%s\" command...", cmd) + "... [" + color.RedString("FAIL") + "]\n"
 			status.Stop()
 			return cli.NewExitError(color.RedString("unable to remove directory: %s", repoDir), 1)
 		}
 
+		removePackageState(repoDir)
+
 		status.Stop()
 	}
 
+	updateLockfile()
+
 	return nil
 }
 
 func cmdUpgrade(c *cli.Context) error {
+	if channel := c.String("channel"); channel != "" {
+		if err := validateUpgradeChannel(channel); err != nil {
+			return cli.NewExitError(color.RedString(err.Error()), 1)
+		}
+
+		err := updateConfig(func() {
+			setConfigValue("cli", "upgrade-channel", channel)
+			setConfigValue("cli", "latest-version", "")
+		})
+		if err != nil {
+			return cli.NewExitError(color.RedString("Unable to save config: %s", err.Error()), 1)
+		}
+		fmt.Printf("Switched to the %s release channel\n", color.BlueString(channel))
+	}
+
+	if c.Bool("check") {
+		return checkUpgradeStatus()
+	}
+
+	if c.Bool("notes") {
+		latestVersion := getLatestReleaseVersion()
+		if latestVersion == "0" {
+			return cli.NewExitError(color.RedString("Unable to determine the latest version"), 1)
+		}
+
+		if !isUpgradeAvailable(getUpgradeChannel(), VERSION, latestVersion) {
+			fmt.Printf("Akamai CLI (%s) is already up-to-date\n", color.CyanString("v"+VERSION))
+			return nil
+		}
+
+		fmt.Printf("Latest version: %s (current version: %s)\n", color.BlueString("v"+latestVersion), color.BlueString("v"+VERSION))
+		if err := printReleaseNotes(getReleaseURL(), VERSION, latestVersion); err != nil {
+			return cli.NewExitError(color.RedString("Unable to fetch release notes: %s", err.Error()), 1)
+		}
+
+		return nil
+	}
+
+	if c.Bool("rollback") {
+		if !rollbackCli() {
+			return cli.NewExitError("", 1)
+		}
+
+		return nil
+	}
+
+	if version := strings.TrimPrefix(c.String("to"), "v"); version != "" {
+		if version == VERSION {
+			fmt.Printf("Akamai CLI (%s) is already installed", color.CyanString("v"+VERSION))
+			return nil
+		}
+
+		fmt.Printf("Installing version: %s (current version: %s)\n", color.BlueString("v"+version), color.BlueString("v"+VERSION))
+		os.Args = []string{os.Args[0], "--version"}
+		if !upgradeCli(version) {
+			return cli.NewExitError("", 1)
+		}
+
+		return nil
+	}
+
 	status := getSpinner("Checking for upgrades...", "Checking for upgrades...... ["+color.GreenString("OK")+"]\n")
 
 	status.Start()
@@ -274,6 +486,39 @@ func cmdUpgrade(c *cli.Context) error {
 	return nil
 }
 
+type upgradeStatus struct {
+	Current string `json:"current"`
+	Latest  string `json:"latest,omitempty"`
+	Channel string `json:"channel"`
+	Upgrade bool   `json:"upgrade"`
+	Error   string `json:"error,omitempty"`
+}
+
+// checkUpgradeStatus prints whether an upgrade is available as JSON, and exits with upgradeAvailableExitCode if it is
+func checkUpgradeStatus() error {
+	status := upgradeStatus{Current: VERSION, Channel: getUpgradeChannel()}
+
+	latestVersion, err := getChannelVersion(getReleaseURL(), status.Channel)
+	if err != nil {
+		status.Error = err.Error()
+	} else {
+		status.Latest = latestVersion
+		status.Upgrade = isUpgradeAvailable(status.Channel, VERSION, latestVersion)
+	}
+
+	data, _ := json.Marshal(status)
+	fmt.Println(string(data))
+
+	switch {
+	case err != nil:
+		return cli.NewExitError("", 1)
+	case status.Upgrade:
+		return cli.NewExitError("", upgradeAvailableExitCode)
+	}
+
+	return nil
+}
+
 func cmdSubcommand(c *cli.Context) error {
 	cachePath, err := getAkamaiCliCachePath()
 	if err != nil {
@@ -297,6 +542,11 @@ func cmdSubcommand(c *cli.Context) error {
 
 	cmdPackage, _ := readPackage(packageDir)
 
+	if packageDir != "" {
+		exportPackageConfigEnv(getPackageName(packageDir), cmdPackage.Settings)
+	}
+	exportCredentialsEnv()
+
 	if cmdPackage.Requirements.Python != "" {
 		os.Setenv("PYTHONUSERBASE", packageDir)
 		if err != nil {
@@ -304,7 +554,13 @@ func cmdSubcommand(c *cli.Context) error {
 		}
 	}
 
-	executable = append(executable, os.Args[2:]...)
+	for _, command := range cmdPackage.Commands {
+		if command.Name == cmd {
+			executable = append(executable, getCredentialArgs(command)...)
+		}
+	}
+
+	executable = append(executable, c.Args()...)
 	return passthruCommand(executable)
 }
 
@@ -353,15 +609,27 @@ func getBuiltinCommands() []commandPackage {
 			Commands: []Command{
 				{
 					Name:      "install",
-					Arguments: "<package name or repository URL>...",
+					Arguments: "<package name or repository URL>[@<ref>]...",
 					Flags: []cli.Flag{
 						cli.BoolFlag{
 							Name:  "force",
 							Usage: "Force binary installation if available when source installation fails",
 						},
+						cli.BoolFlag{
+							Name:  "link",
+							Usage: "Register local package directories instead of cloning a repository, changes take effect immediately",
+						},
+						cli.BoolFlag{
+							Name:  "from-lock",
+							Usage: "Install the packages listed in a lockfile created by \"akamai lock\"",
+						},
+						cli.IntFlag{
+							Name:  "jobs",
+							Usage: "Maximum number of packages to install at the same time (default: 4)",
+						},
 					},
 					Description: "Fetch and install packages from a Git repository.",
-					Docs:        "Examples:\n\n   akamai install property purge\n   akamai install akamai/cli-property\n   akamai install git@github.com:akamai/cli-property.git\n   akamai install https://github.com/akamai/cli-property.git",
+					Docs:        "Examples:\n\n   akamai install property purge\n   akamai install property@v1.2.0\n   akamai install akamai/cli-property\n   akamai install git@github.com:akamai/cli-property.git\n   akamai install https://github.com/akamai/cli-property.git\n   akamai install --from-lock akamai-cli.lock\n   akamai install --link ./cli-example",
 				},
 			},
 			action: cmdInstall,
@@ -386,12 +654,148 @@ func getBuiltinCommands() []commandPackage {
 							Name:  "force",
 							Usage: "Force binary installation if available when source installation fails",
 						},
+						cli.IntFlag{
+							Name:  "jobs",
+							Usage: "Maximum number of packages to update at the same time (default: 4)",
+						},
+						cli.StringFlag{
+							Name:  "strategy",
+							Usage: "Set how the package is updated: \"head\" follows the default branch, \"tag\" follows the latest version tag. Removes any pinned ref",
+						},
 					},
 					Description: "Update one or more commands. If no command is specified, all commands are updated",
 				},
 			},
 			action: cmdUpdate,
 		},
+		{
+			Commands: []Command{
+				{
+					Name:        "rollback",
+					Arguments:   "<command>...",
+					Description: "Roll back the package containing <command> to the version installed before the last update",
+				},
+			},
+			action: cmdRollback,
+		},
+		{
+			Commands: []Command{
+				{
+					Name:        "outdated",
+					Description: "Check installed packages for updates without installing them",
+				},
+			},
+			action: cmdOutdated,
+		},
+		{
+			Commands: []Command{
+				{
+					Name:        "info",
+					Arguments:   "<command>",
+					Description: "Display information about the package containing <command>",
+				},
+			},
+			action: cmdInfo,
+		},
+		{
+			Commands: []Command{
+				{
+					Name:        "search",
+					Arguments:   "[<term>...]",
+					Description: "Search for packages in the package index",
+					Docs:        "Examples:\n\n   akamai search property\n   akamai search purge cache",
+				},
+			},
+			action: cmdSearch,
+		},
+		{
+			Commands: []Command{
+				{
+					Name:        "lock",
+					Arguments:   "[<file>]",
+					Description: "Export a lockfile of installed packages to <file>, or stdout if no file is specified",
+					Docs:        "Examples:\n\n   akamai lock akamai-cli.lock\n   akamai install --from-lock akamai-cli.lock",
+				},
+			},
+			action: cmdLock,
+		},
+		{
+			Commands: []Command{
+				{
+					Name:        "config",
+					Arguments:   "<get|set|unset|list> [<section.key>] [<value>]",
+					Description: "Display or change Akamai CLI settings",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "json",
+							Usage: "Output as JSON",
+						},
+					},
+					Docs: "Examples:\n\n   akamai config list\n   akamai config list cli --json\n   akamai config get cli.last-upgrade-check\n   akamai config set cli.jobs 8\n   akamai config unset cli.jobs",
+				},
+			},
+			action: cmdConfig,
+		},
+		{
+			Commands: []Command{
+				{
+					Name:        "edgerc",
+					Arguments:   "<list|add|remove|show|verify> [<section>]",
+					Description: "Manage the credentials in your .edgerc file",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "file",
+							Usage: "Import the credentials file downloaded when creating an API client, instead of prompting for them",
+						},
+						cli.BoolFlag{
+							Name:  "force",
+							Usage: "Replace the section if it already exists",
+						},
+					},
+					Docs: "Examples:\n\n   akamai edgerc list\n   akamai edgerc add papi --file ~/Downloads/credentials.txt\n   akamai edgerc show papi\n   akamai edgerc verify\n   akamai edgerc remove papi",
+				},
+			},
+			action: cmdEdgerc,
+		},
+		{
+			Commands: []Command{
+				{
+					Name:        "http",
+					Aliases:     []string{"curl"},
+					Arguments:   "<METHOD> <path>",
+					Description: "Make an API request signed with the credentials in your .edgerc file",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "body, b",
+							Usage: "Send `JSON` as the request body. Use @<file> to read it from a file, or @- from stdin",
+						},
+						cli.StringSliceFlag{
+							Name:  "header, H",
+							Usage: "Add a request header, in the format \"Name: value\"",
+						},
+						cli.StringSliceFlag{
+							Name:  "query, q",
+							Usage: "Add a query parameter, in the format name=value",
+						},
+						cli.BoolFlag{
+							Name:  "include, i",
+							Usage: "Show the response status and headers",
+						},
+						cli.BoolFlag{
+							Name:  "raw",
+							Usage: "Do not format JSON responses",
+						},
+						cli.IntFlag{
+							Name:  "timeout",
+							Value: 60,
+							Usage: "Give up after `SECONDS`",
+						},
+					},
+					Docs: "Examples:\n\n   akamai http GET /papi/v1/groups\n   akamai --section ccu http POST /ccu/v3/invalidate/url --body '{\"objects\": [\"https://example.com/\"]}'\n   akamai http GET /papi/v1/properties -q contractId=ctr_1-ABCDE -q groupId=grp_12345",
+				},
+			},
+			action: cmdHTTP,
+		},
 	}
 
 	upgradeCommand := getUpgradeCommand()
@@ -464,33 +868,40 @@ func getAkamaiCliCachePath() (string, error) {
 }
 
 func getPackagePaths() string {
-	path := ""
+	var paths []string
 	akamaiCliPath, err := getAkamaiCliSrcPath()
 	if err == nil && akamaiCliPath != "" {
-		paths, _ := filepath.Glob(filepath.Join(akamaiCliPath, "*"))
-		if len(paths) > 0 {
-			path += strings.Join(paths, string(os.PathListSeparator))
-		}
+		paths, _ = filepath.Glob(filepath.Join(akamaiCliPath, "*"))
 	}
 
-	return path
+	paths = append(paths, getLinkedPackageDirs()...)
+
+	return strings.Join(paths, string(os.PathListSeparator))
 }
 
 func getPackageBinPaths() string {
-	path := ""
+	var paths []string
 	akamaiCliPath, err := getAkamaiCliSrcPath()
 	if err == nil && akamaiCliPath != "" {
-		paths, _ := filepath.Glob(filepath.Join(akamaiCliPath, "*"))
-		if len(paths) > 0 {
-			path += strings.Join(paths, string(os.PathListSeparator))
-		}
-		paths, _ = filepath.Glob(filepath.Join(akamaiCliPath, "*", "bin"))
-		if len(paths) > 0 {
-			path += string(os.PathListSeparator) + strings.Join(paths, string(os.PathListSeparator))
+		srcPaths, _ := filepath.Glob(filepath.Join(akamaiCliPath, "*"))
+		paths = append(paths, srcPaths...)
+	}
+
+	linkedPaths := getLinkedPackageDirs()
+	paths = append(paths, linkedPaths...)
+
+	if err == nil && akamaiCliPath != "" {
+		binPaths, _ := filepath.Glob(filepath.Join(akamaiCliPath, "*", "bin"))
+		paths = append(paths, binPaths...)
+	}
+
+	for _, linkedPath := range linkedPaths {
+		if _, err := os.Stat(filepath.Join(linkedPath, "bin")); err == nil {
+			paths = append(paths, filepath.Join(linkedPath, "bin"))
 		}
 	}
 
-	return path
+	return strings.Join(paths, string(os.PathListSeparator))
 }
 
 func listDiff(oldcmds []commandPackage) {
@@ -600,22 +1011,28 @@ type commandPackage struct {
 		Python string `json:"python"`
 	} `json:"requirements"`
 
+	Settings []packageSetting `json:"settings"`
+
 	action interface{}
 }
 
 type Command struct {
-	Name        string     `json:"name"`
-	Aliases     []string   `json:"aliases"`
-	Version     string     `json:"version"`
-	Description string     `json:"description"`
-	Usage       string     `json:"usage"`
-	Docs        string     `json:-`
-	Arguments   string     `json:"arguments"`
-	Flags       []cli.Flag `json:"-"`
-	Bin         string     `json:"bin"`
-	BinSuffix   string     `json:"-"`
-	OS          string     `json:"-"`
-	Arch        string     `json:"-"`
+	Name            string            `json:"name"`
+	Aliases         []string          `json:"aliases"`
+	Version         string            `json:"version"`
+	Description     string            `json:"description"`
+	Usage           string            `json:"usage"`
+	Docs            string            `json:-`
+	Arguments       string            `json:"arguments"`
+	Flags           []cli.Flag        `json:"-"`
+	Bin             string            `json:"bin"`
+	Checksums       map[string]string `json:"checksums"`
+	ChecksumURL     string            `json:"checksum-url"`
+	SignatureURL    string            `json:"signature-url"`
+	CredentialFlags []string          `json:"credential-flags"`
+	BinSuffix       string            `json:"-"`
+	OS              string            `json:"-"`
+	Arch            string            `json:"-"`
 }
 
 func readPackage(dir string) (commandPackage, error) {
@@ -644,7 +1061,7 @@ func readPackage(dir string) (commandPackage, error) {
 	return packageData, nil
 }
 
-func installPackage(dir string, forceBinary bool) bool {
+func installPackage(dir string, binary binaryInstall) bool {
 	status := getSpinner("Installing...", "Installing...... ["+color.GreenString("OK")+"]\n")
 
 	status.Start()
@@ -657,6 +1074,12 @@ func installPackage(dir string, forceBinary bool) bool {
 		return false
 	}
 
+	if binary == binaryOnly {
+		status.FinalMSG = ""
+		status.Stop()
+		return installBinary(dir, cmdPackage)
+	}
+
 	lang := determineCommandLanguage(cmdPackage)
 
 	var success bool
@@ -675,56 +1098,69 @@ func installPackage(dir string, forceBinary bool) bool {
 		status.FinalMSG = "Installing...... [" + color.CyanString("OK") + "]\n"
 		status.Stop()
 		color.Cyan("Package installed successfully, however package type is unknown, and may or may not function correctly.")
+		setPackageMethod(dir, installMethodSource)
 		return true
 	}
 
 	if success && err == nil {
 		status.Stop()
+		setPackageMethod(dir, installMethodSource)
 		return true
 	}
 
-	first := true
 	for _, cmd := range cmdPackage.Commands {
 		if cmd.Bin != "" {
-			if first {
-				first = false
-				status.FinalMSG = "Installing...... [" + color.CyanString("WARN") + "]\n"
-				status.Stop()
-				color.Cyan(err.Error())
-				if !forceBinary {
-					if !isatty.IsTerminal(os.Stdout.Fd()) && !isatty.IsCygwinTerminal(os.Stdout.Fd()) {
-						return false
-					}
-
-					fmt.Print("Binary command(s) found, would you like to try download and install it? (Y/n): ")
-					answer := ""
-					fmt.Scanln(&answer)
-					if answer != "" && strings.ToLower(answer) != "y" {
-						return false
-					}
+			status.FinalMSG = "Installing...... [" + color.CyanString("WARN") + "]\n"
+			status.Stop()
+			color.Cyan(err.Error())
+			if binary != binaryIfSourceFails {
+				if !isatty.IsTerminal(os.Stdout.Fd()) && !isatty.IsCygwinTerminal(os.Stdout.Fd()) {
+					return false
 				}
 
-				os.MkdirAll(filepath.Join(dir, "bin"), 0775)
+				fmt.Print("Binary command(s) found, would you like to try download and install it? (Y/n): ")
+				answer := ""
+				fmt.Scanln(&answer)
+				if answer != "" && strings.ToLower(answer) != "y" {
+					return false
+				}
 			}
 
-			status := getSpinner("Downloading binary...", "Downloading binary...... ["+color.GreenString("OK")+"]\n")
-			status.Start()
-			if downloadBin(filepath.Join(dir, "bin"), cmd) {
-				status.Stop()
-				return true
-			} else {
-				status.FinalMSG = "Downloading binary...... [" + color.RedString("FAIL") + "]\n"
-				status.Stop()
-				color.Red("Unable to download binary: " + err.Error())
-				return false
-			}
+			return installBinary(dir, cmdPackage)
 		}
 	}
 
 	return true
 }
 
-func updatePackage(cmd string, forceBinary bool) error {
+// installBinary downloads the binary of the first command that has one, instead of installing from source
+func installBinary(dir string, cmdPackage commandPackage) bool {
+	for _, cmd := range cmdPackage.Commands {
+		if cmd.Bin == "" {
+			continue
+		}
+
+		os.MkdirAll(filepath.Join(dir, "bin"), 0775)
+
+		status := getSpinner("Downloading binary...", "Downloading binary...... ["+color.GreenString("OK")+"]\n")
+		status.Start()
+		if err := downloadBin(filepath.Join(dir, "bin"), cmd); err != nil {
+			status.FinalMSG = "Downloading binary...... [" + color.RedString("FAIL") + "]\n"
+			status.Stop()
+			color.Red("Unable to download binary: " + err.Error())
+			return false
+		}
+
+		status.Stop()
+		setPackageMethod(dir, installMethodBinary)
+		return true
+	}
+
+	color.Red("Package has no binary commands to download")
+	return false
+}
+
+func updatePackage(cmd string, forceBinary bool, strategy string) error {
 	exec, err := findExec(cmd)
 	if err != nil {
 		return cli.NewExitError(color.RedString("Command \"%s\" not found. Try \"%s help\".\n", cmd, self()), 1)
@@ -746,54 +1182,62 @@ func updatePackage(cmd string, forceBinary bool) error {
 		return cli.NewExitError(color.RedString("unable to update, was it installed using "+color.CyanString("\"akamai install\"")+"?"), 1)
 	}
 
+	if getPackageState(repoDir).Link == repoDir {
+		status.FinalMSG = fmt.Sprintf("Attempting to update \"%s\" command...", cmd) + "... [" + color.CyanString("OK") + "]\n"
+		status.Stop()
+		color.Cyan("command \"%s\" is linked to %s, and is always up-to-date", cmd, repoDir)
+		return nil
+	}
+
 	repo, err := git.PlainOpen(repoDir)
 	if err != nil {
 		return err
 	}
 
-	err = repo.Fetch(&git.FetchOptions{
-		RemoteName: git.DefaultRemoteName,
-	})
-
-	if err != nil && err.Error() != "already up-to-date" {
+	err = fetchRemote(repo)
+	if err != nil {
 		status.FinalMSG = fmt.Sprintf("Attempting to update \"%s\" command...", cmd) + "... [" + color.RedString("FAIL") + "]\n"
 		status.Stop()
 		return cli.NewExitError("Unable to fetch updates", 1)
 	}
 
-	workdir, _ := repo.Worktree()
-	ref, err := repo.Reference("refs/remotes/"+git.DefaultRemoteName+"/master", true)
+	state := getPackageState(repoDir)
+	if strategy != "" {
+		state.Ref = ""
+		state.Strategy = strategy
+		setPackageState(repoDir, state)
+	}
+
+	hash, err := resolveUpdateTarget(repo, state)
 	if err != nil {
 		status.FinalMSG = fmt.Sprintf("Attempting to update \"%s\" command...", cmd) + "... [" + color.RedString("FAIL") + "]\n"
 		status.Stop()
-		return cli.NewExitError("Unable to update command", 1)
+		return cli.NewExitError("Unable to update command: "+err.Error(), 1)
 	}
 
 	head, _ := repo.Head()
-	if head.Hash() == ref.Hash() {
+	if head.Hash() == hash {
 		status.FinalMSG = fmt.Sprintf("Attempting to update \"%s\" command...", cmd) + "... [" + color.CyanString("OK") + "]\n"
 		status.Stop()
-		color.Cyan("command \"%s\" already up-to-date", cmd)
+		if state.Ref != "" {
+			color.Cyan("command \"%s\" already up-to-date at %s (pinned to %s)", cmd, describeCommit(repo, hash), state.Ref)
+		} else {
+			color.Cyan("command \"%s\" already up-to-date at %s", cmd, describeCommit(repo, hash))
+		}
 		return nil
 	}
 
-	err = workdir.Checkout(&git.CheckoutOptions{
-		Branch: ref.Name(),
-		Force:  true,
-	})
-
-	if err != nil {
-		status.FinalMSG = fmt.Sprintf("Attempting to update \"%s\" command...", cmd) + "... [" + color.RedString("FAIL") + "]\n"
-		status.Stop()
-		return cli.NewExitError("Unable to update command", 1)
-	}
-
 	status.Stop()
+	fmt.Printf("Updating \"%s\" from %s to %s\n", cmd, color.BlueString(describeCommit(repo, head.Hash())), color.BlueString(describeCommit(repo, hash)))
 
-	if !installPackage(repoDir, forceBinary) {
-		return cli.NewExitError("Unable to update command", 1)
+	if !installCommit(repoDir, hash, getBinaryInstall(forceBinary)) {
+		return cli.NewExitError(color.RedString("Unable to update command, \"%s\" has been left at %s", cmd, describeCommit(repo, head.Hash())), 1)
 	}
 
+	state = getPackageState(repoDir)
+	state.pushHistory(head.Hash().String())
+	setPackageState(repoDir, state)
+
 	return nil
 }
 
@@ -1133,49 +1577,73 @@ func determineCommandLanguage(cmdPackage commandPackage) string {
 	return ""
 }
 
-func downloadBin(dir string, cmd Command) bool {
-	cmd.Arch = runtime.GOARCH
+func downloadBin(dir string, cmd Command) error {
+	cmd = getBinCommand(cmd)
 
-	cmd.OS = runtime.GOOS
-	if runtime.GOOS == "darwin" {
-		cmd.OS = "mac"
+	url, err := renderBinTemplate(cmd.Bin, cmd)
+	if err != nil {
+		return err
 	}
 
-	if runtime.GOOS == "windows" {
-		cmd.BinSuffix = ".exe"
+	checksum, err := getBinChecksum(cmd)
+	if err != nil {
+		return err
 	}
 
-	t := template.Must(template.New("url").Parse(cmd.Bin))
-	buf := &bytes.Buffer{}
-	if err := t.Execute(buf, cmd); err != nil {
-		return false
+	res, err := http.Get(url)
+	if err != nil {
+		return err
 	}
+	defer res.Body.Close()
 
-	url := buf.String()
+	if res.StatusCode != 200 {
+		return fmt.Errorf("unexpected response: %s", res.Status)
+	}
 
-	bin, err := os.Create(filepath.Join(dir, "akamai-" + strings.ToLower(cmd.Name) + cmd.BinSuffix))
-	bin.Chmod(0775)
+	// Download to a temporary file, so that a binary is only installed once it has been verified
+	binPath := filepath.Join(dir, "akamai-"+strings.ToLower(cmd.Name)+cmd.BinSuffix)
+	bin, err := ioutil.TempFile(dir, ".download-")
 	if err != nil {
-		return false
+		return err
 	}
-	defer bin.Close()
+	defer os.Remove(bin.Name())
 
-	res, err := http.Get(url)
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(bin, hash), res.Body)
+	bin.Close()
 	if err != nil {
-		return false
+		return err
 	}
-	defer res.Body.Close()
 
-	if res.StatusCode != 200 {
-		return false
+	if n == 0 {
+		return fmt.Errorf("empty response from %s", url)
 	}
 
-	n, err := io.Copy(bin, res.Body)
-	if err != nil || n == 0 {
-		return false
+	if checksum != "" && checksum != hex.EncodeToString(hash.Sum(nil)) {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", url, checksum, hex.EncodeToString(hash.Sum(nil)))
 	}
 
-	return true
+	signatureURL := ""
+	if cmd.SignatureURL != "" {
+		if signatureURL, err = renderBinTemplate(cmd.SignatureURL, cmd); err != nil {
+			return err
+		}
+	}
+
+	data, err := ioutil.ReadFile(bin.Name())
+	if err != nil {
+		return err
+	}
+
+	if err := verifyDownload(data, signatureURL); err != nil {
+		return fmt.Errorf("unable to verify %s: %s", url, err.Error())
+	}
+
+	if err := os.Chmod(bin.Name(), 0775); err != nil {
+		return err
+	}
+
+	return os.Rename(bin.Name(), binPath)
 }
 
 func self() string {
@@ -1293,6 +1761,24 @@ func passthruCommand(executable []string) error {
 	return nil
 }
 
+func splitRepoRef(repo string) (string, string) {
+	i := strings.LastIndex(repo, "@")
+	if i == -1 {
+		return repo, ""
+	}
+
+	ref := repo[i+1:]
+	// Skip ssh user info (git@github.com:org/repo.git) and http credentials (https://user@host/repo.git)
+	if ref == "" || strings.Contains(ref, ":") {
+		return repo, ""
+	}
+	if scheme := strings.Index(repo, "://"); scheme != -1 && !strings.Contains(repo[scheme+3:i], "/") {
+		return repo, ""
+	}
+
+	return repo[:i], ref
+}
+
 func githubize(repo string) string {
 	if strings.HasPrefix(repo, "http") || strings.HasPrefix(repo, "ssh") || strings.HasSuffix(repo, ".git") {
 		return strings.TrimPrefix(repo, "ssh://")
@@ -1314,9 +1800,15 @@ func findPackageDir(dir string) string {
 	   dir = path.Dir(dir)
 	}
 
+	for _, linkedDir := range getLinkedPackageDirs() {
+		if dir == linkedDir || strings.HasPrefix(dir, linkedDir+string(filepath.Separator)) {
+			return linkedDir
+		}
+	}
+
 	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
 	   	if os.IsNotExist(err) {
-			if path.Dir(dir) == "" {
+			if filepath.Dir(dir) == dir {
 				return ""
 			}
 
@@ -1328,12 +1820,15 @@ func findPackageDir(dir string) string {
 }
 
 func versionCompare(left string, right string) int {
+	left, leftPrerelease := splitPrerelease(left)
+	right, rightPrerelease := splitPrerelease(right)
+
 	leftParts := strings.Split(left, ".")
 	leftMajor, _ := strconv.Atoi(leftParts[0])
 	leftMinor := 0
 	leftMicro := 0
 
-	if left == right {
+	if left == right && leftPrerelease == rightPrerelease {
 		return 0
 	}
 
@@ -1368,15 +1863,95 @@ func versionCompare(left string, right string) int {
 		return -1
 	}
 
+	// Pre-releases come before the release they precede, e.g. 1.0.0-beta.1 < 1.0.0
+	if leftMajor == rightMajor && leftMinor == rightMinor && leftMicro == rightMicro && leftPrerelease != rightPrerelease {
+		if leftPrerelease == "" || (rightPrerelease != "" && comparePrerelease(leftPrerelease, rightPrerelease) == -1) {
+			return -1
+		}
+	}
+
 	return 1
 }
 
-func getSpinner(prefix string, finalMsg string) *spinner.Spinner {
+func splitPrerelease(version string) (string, string) {
+	if i := strings.Index(version, "-"); i != -1 {
+		return version[:i], version[i+1:]
+	}
+
+	return version, ""
+}
+
+// comparePrerelease compares pre-release identifiers the way semver does: dot separated identifiers are compared
+// in turn, numerically if both are numbers, and numbers sort before other identifiers. Like versionCompare, it
+// returns -1 if left is the later pre-release, and 1 if right is.
+func comparePrerelease(left string, right string) int {
+	leftParts := strings.Split(left, ".")
+	rightParts := strings.Split(right, ".")
+
+	for i := 0; i < len(leftParts) && i < len(rightParts); i++ {
+		leftNumber, leftErr := strconv.Atoi(leftParts[i])
+		rightNumber, rightErr := strconv.Atoi(rightParts[i])
+
+		switch {
+		case leftErr == nil && rightErr == nil:
+			if leftNumber != rightNumber {
+				if leftNumber > rightNumber {
+					return -1
+				}
+				return 1
+			}
+		case leftErr == nil:
+			return 1
+		case rightErr == nil:
+			return -1
+		case leftParts[i] != rightParts[i]:
+			if leftParts[i] > rightParts[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	if len(leftParts) > len(rightParts) {
+		return -1
+	}
+	if len(leftParts) < len(rightParts) {
+		return 1
+	}
+
+	return 0
+}
+
+// progressSpinner only animates when stdout is a terminal, otherwise it just prints the final message
+type progressSpinner struct {
+	*spinner.Spinner
+	animate bool
+}
+
+func (status *progressSpinner) Start() {
+	if status.animate {
+		status.Spinner.Start()
+	}
+}
+
+func (status *progressSpinner) Stop() {
+	if status.animate {
+		status.Spinner.Stop()
+		return
+	}
+
+	fmt.Print(status.FinalMSG)
+}
+
+func getSpinner(prefix string, finalMsg string) *progressSpinner {
 	status := spinner.New(spinner.CharSets[26], 500*time.Millisecond)
 	status.Prefix = prefix
 	status.FinalMSG = finalMsg
 
-	return status
+	return &progressSpinner{
+		Spinner: status,
+		animate: isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd()),
+	}
 }
 
 func showBanner() {
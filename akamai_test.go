@@ -1,6 +1,51 @@
 package main
 
-import "testing"
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-ini/ini"
+	"github.com/urfave/cli"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/ed25519"
+	"gopkg.in/src-d/go-git.v4"
+	gitconfig "gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+)
+
+// TestMain lets the test binary act as a parallel worker (see runParallelJobs), which prints its arguments, and
+// fails for targets starting with "fail"
+func TestMain(m *testing.M) {
+	if isParallelWorker() {
+		fmt.Println(strings.Join(os.Args[1:], " "))
+		if strings.HasPrefix(os.Args[len(os.Args)-1], "fail") {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
 
 func TestVersionCompare(t *testing.T) {
 	versionTests := []struct {
@@ -18,6 +63,18 @@ func TestVersionCompare(t *testing.T) {
 		{"1", "2", 1},
 		{"1.1", "1.2", 1},
 		{"3.0.0", "3.1.4", 1},
+		{"1.0.0-beta.1", "1.0.0", 1},
+		{"1.0.0", "1.0.0-beta.1", -1},
+		{"1.0.0-beta.1", "1.0.0-beta.2", 1},
+		{"1.0.0-beta.2", "1.0.0-beta.1", -1},
+		{"1.0.0-beta.1", "1.0.0-beta.1", 0},
+		{"0.9.0", "1.0.0-beta.1", 1},
+		{"1.0.0-beta.1", "0.9.0", -1},
+		{"1.0.0-beta.9", "1.0.0-beta.10", 1},
+		{"1.0.0-beta.10", "1.0.0-beta.9", -1},
+		{"1.0.0-beta", "1.0.0-beta.1", 1},
+		{"1.0.0-alpha.1", "1.0.0-beta", 1},
+		{"1.0.0-beta.2", "1.0.0-beta.a", 1},
 	}
 
 	for _, tt := range versionTests {
@@ -26,3 +83,1741 @@ func TestVersionCompare(t *testing.T) {
 		}
 	}
 }
+
+func TestSplitRepoRef(t *testing.T) {
+	repoTests := []struct {
+		input string
+		repo  string
+		ref   string
+	}{
+		{"property", "property", ""},
+		{"property@v1.2.0", "property", "v1.2.0"},
+		{"akamai/cli-property@feature/foo", "akamai/cli-property", "feature/foo"},
+		{"git@github.com:akamai/cli-property.git", "git@github.com:akamai/cli-property.git", ""},
+		{"git@github.com:akamai/cli-property.git@v1.2.0", "git@github.com:akamai/cli-property.git", "v1.2.0"},
+		{"https://user@github.com/akamai/cli-property.git", "https://user@github.com/akamai/cli-property.git", ""},
+		{"https://github.com/akamai/cli-property.git@3f9d52f", "https://github.com/akamai/cli-property.git", "3f9d52f"},
+		{"property@", "property@", ""},
+	}
+
+	for _, tt := range repoTests {
+		if repo, ref := splitRepoRef(tt.input); repo != tt.repo || ref != tt.ref {
+			t.Errorf("splitRepoRef(%s) => (%s, %s), wanted: (%s, %s)", tt.input, repo, ref, tt.repo, tt.ref)
+		}
+	}
+}
+
+func TestShortenRepo(t *testing.T) {
+	repoTests := []struct {
+		repo  string
+		short string
+	}{
+		{"https://github.com/akamai/cli-property", "property"},
+		{"https://github.com/akamai/cli-property.git", "property"},
+		{"https://github.com/akamai/cli", "akamai/cli"},
+		{"https://github.com/someone/cli-example/", "someone/cli-example"},
+		{"https://gitlab.com/someone/cli-example.git", "https://gitlab.com/someone/cli-example.git"},
+		{"git@github.com:akamai/cli-property.git", "git@github.com:akamai/cli-property.git"},
+	}
+
+	for _, tt := range repoTests {
+		if short := shortenRepo(tt.repo); short != tt.short {
+			t.Errorf("shortenRepo(%s) => %s, wanted: %s", tt.repo, short, tt.short)
+		}
+
+		if strings.HasPrefix(tt.repo, "https://github.com/") && githubize(tt.short) != strings.TrimSuffix(strings.TrimSuffix(tt.repo, "/"), ".git")+".git" {
+			t.Errorf("githubize(%s) => %s, wanted: %s", tt.short, githubize(tt.short), tt.repo)
+		}
+	}
+}
+
+func TestDownloadBin(t *testing.T) {
+	home, err := ioutil.TempDir("", "akamai-cli-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	os.Setenv("AKAMAI_CLI_HOME", home)
+	defer os.Unsetenv("AKAMAI_CLI_HOME")
+
+	binary := []byte("#!/bin/sh\necho demo\n")
+	sum := sha256.Sum256(binary)
+	checksum := hex.EncodeToString(sum[:])
+	wrong := strings.Repeat("0", 64)
+
+	public, private, _ := ed25519.GenerateKey(nil)
+	_, otherPrivate, _ := ed25519.GenerateKey(nil)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/demo":
+			w.Write(binary)
+		case "/demo.sha256":
+			fmt.Fprintf(w, "%s  akamai-demo\n", checksum)
+		case "/demo.bad.sha256":
+			fmt.Fprintln(w, wrong)
+		case "/demo.minisig":
+			fmt.Fprintln(w, base64.StdEncoding.EncodeToString(ed25519.Sign(private, binary)))
+		case "/demo.bad.minisig":
+			fmt.Fprintln(w, base64.StdEncoding.EncodeToString(ed25519.Sign(otherPrivate, binary)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	platform := getBinCommand(Command{}).OS + "-" + getBinCommand(Command{}).Arch
+
+	binTests := []struct {
+		name         string
+		checksums    map[string]string
+		checksumURL  string
+		signatureURL string
+		trustedKeys  string
+		strict       bool
+		success      bool
+	}{
+		{"no checksum", nil, "", "", "", false, true},
+		{"matching checksum", map[string]string{platform: checksum}, "", "", "", false, true},
+		{"matching prefixed checksum", map[string]string{platform: "sha256:" + strings.ToUpper(checksum)}, "", "", "", false, true},
+		{"mismatched checksum", map[string]string{platform: wrong}, "", "", "", false, false},
+		{"invalid checksum", map[string]string{platform: "abc"}, "", "", "", false, false},
+		{"other platform checksum", map[string]string{"plan9-mips": wrong}, "", "", "", false, true},
+		{"matching checksum URL", nil, server.URL + "/{{.Name}}.sha256", "", "", false, true},
+		{"mismatched checksum URL", nil, server.URL + "/{{.Name}}.bad.sha256", "", "", false, false},
+		{"missing checksum URL", nil, server.URL + "/{{.Name}}.missing", "", "", false, false},
+		{"checksums take precedence", map[string]string{platform: checksum}, server.URL + "/{{.Name}}.bad.sha256", "", "", false, true},
+		{"valid signature", nil, "", server.URL + "/{{.Name}}.minisig", base64.StdEncoding.EncodeToString(public), false, true},
+		{"invalid signature", nil, "", server.URL + "/{{.Name}}.bad.minisig", base64.StdEncoding.EncodeToString(public), false, false},
+		{"missing signature", nil, "", server.URL + "/{{.Name}}.missing", base64.StdEncoding.EncodeToString(public), false, false},
+		{"missing signature without trusted keys", nil, "", server.URL + "/{{.Name}}.missing", "", false, true},
+		{"unsigned", nil, "", "", base64.StdEncoding.EncodeToString(public), false, true},
+		{"missing signature in strict mode", nil, "", server.URL + "/{{.Name}}.missing", base64.StdEncoding.EncodeToString(public), true, false},
+		{"unsigned in strict mode", nil, "", "", base64.StdEncoding.EncodeToString(public), true, false},
+		{"signature without trusted keys", nil, "", server.URL + "/{{.Name}}.minisig", "", false, true},
+		{"signature without trusted keys in strict mode", nil, "", server.URL + "/{{.Name}}.minisig", "", true, false},
+		{"valid signature in strict mode", nil, "", server.URL + "/{{.Name}}.minisig", base64.StdEncoding.EncodeToString(public), true, true},
+	}
+
+	for _, tt := range binTests {
+		setConfigValue("cli", "trusted-keys", tt.trustedKeys)
+		setConfigValue("cli", "require-signatures", strconv.FormatBool(tt.strict))
+
+		dir, err := ioutil.TempDir("", "akamai-cli-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		cmd := Command{Name: "demo", Bin: server.URL + "/{{.Name}}", Checksums: tt.checksums, ChecksumURL: tt.checksumURL, SignatureURL: tt.signatureURL}
+		err = downloadBin(dir, cmd)
+		if (err == nil) != tt.success {
+			t.Errorf("downloadBin(%s) => %v, wanted success: %t", tt.name, err, tt.success)
+		}
+
+		files, _ := ioutil.ReadDir(dir)
+		if tt.success && len(files) != 1 {
+			t.Errorf("downloadBin(%s) => %d file(s) written, wanted: 1", tt.name, len(files))
+		}
+		if !tt.success && len(files) != 0 {
+			t.Errorf("downloadBin(%s) => %d file(s) written, wanted: 0", tt.name, len(files))
+		}
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	message := []byte("akamai")
+	public, private, _ := ed25519.GenerateKey(nil)
+	otherPublic, _, _ := ed25519.GenerateKey(nil)
+
+	keyID := []byte("12345678")
+	otherKeyID := []byte("87654321")
+	minisignKey := base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), public...))
+
+	minisign := func(algorithm string, id []byte, signed []byte) []byte {
+		sig := append(append([]byte(algorithm), id...), ed25519.Sign(private, signed)...)
+		return []byte("untrusted comment: signature\n" + base64.StdEncoding.EncodeToString(sig) + "\ntrusted comment: timestamp:0\nAAAA\n")
+	}
+	prehashed := blake2b.Sum512(message)
+
+	signatureTests := []struct {
+		name      string
+		key       string
+		signature []byte
+		valid     bool
+	}{
+		{"raw signature", base64.StdEncoding.EncodeToString(public), []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(private, message))), true},
+		{"raw signature, wrong key", base64.StdEncoding.EncodeToString(otherPublic), []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(private, message))), false},
+		{"minisign legacy signature", minisignKey, minisign("Ed", keyID, message), true},
+		{"minisign prehashed signature", minisignKey, minisign("ED", keyID, prehashed[:]), true},
+		{"minisign signature, raw key", base64.StdEncoding.EncodeToString(public), minisign("Ed", keyID, message), true},
+		{"minisign signature, wrong key id", minisignKey, minisign("Ed", otherKeyID, message), false},
+		{"minisign signature, wrong message", minisignKey, minisign("Ed", keyID, []byte("other")), false},
+		{"invalid signature", minisignKey, []byte("not a signature"), false},
+	}
+
+	for _, tt := range signatureTests {
+		key, err := parsePublicKey(tt.key)
+		if err != nil {
+			t.Fatalf("parsePublicKey(%s) => %s", tt.key, err.Error())
+		}
+
+		if err := verifySignature(message, tt.signature, []trustedKey{key}); (err == nil) != tt.valid {
+			t.Errorf("verifySignature(%s) => %v, wanted valid: %t", tt.name, err, tt.valid)
+		}
+	}
+}
+
+func TestGetChannelVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/akamai/cli/releases/latest":
+			http.Redirect(w, r, "/akamai/cli/releases/tag/0.4.3", http.StatusFound)
+		case "/akamai/cli/releases.atom":
+			fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry><link rel="alternate" type="text/html" href="https://github.com/akamai/cli/releases/tag/0.5.0-beta.1"/></entry>
+  <entry><link rel="alternate" type="text/html" href="https://github.com/akamai/cli/releases/tag/0.4.3"/></entry>
+  <entry><link rel="alternate" type="text/html" href="https://github.com/akamai/cli/releases/tag/0.4.2"/></entry>
+</feed>`)
+		case "/mirror/latest":
+			fmt.Fprintln(w, "0.5.0")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	channelTests := []struct {
+		releaseURL string
+		channel    string
+		version    string
+		success    bool
+	}{
+		{server.URL + "/akamai/cli/releases", "stable", "0.4.3", true},
+		{server.URL + "/akamai/cli/releases", "beta", "0.5.0-beta.1", true},
+		{server.URL + "/akamai/cli/releases", "pinned:0.4.2", "0.4.2", true},
+		{server.URL + "/mirror", "stable", "0.5.0", true},
+		{server.URL + "/mirror", "beta", "", false},
+		{server.URL + "/missing", "stable", "", false},
+		{server.URL + "/akamai/cli/releases", "nightly", "", false},
+		{server.URL + "/akamai/cli/releases", "pinned:", "", false},
+	}
+
+	for _, tt := range channelTests {
+		version, err := getChannelVersion(tt.releaseURL, tt.channel)
+		if (err == nil) != tt.success || version != tt.version {
+			t.Errorf("getChannelVersion(%s, %s) => (%s, %v), wanted: %s", tt.releaseURL, tt.channel, version, err, tt.version)
+		}
+	}
+}
+
+func TestIsUpgradeAvailable(t *testing.T) {
+	upgradeTests := []struct {
+		channel   string
+		current   string
+		version   string
+		available bool
+	}{
+		{"stable", "0.4.3", "0.5.0", true},
+		{"stable", "0.4.3", "0.4.3", false},
+		{"stable", "0.4.3", "0.4.2", false},
+		{"beta", "0.4.3", "0.5.0-beta.1", true},
+		{"beta", "0.5.0", "0.5.0-beta.1", false},
+		{"beta", "0.5.0-beta.9", "0.5.0-beta.10", true},
+		{"beta", "0.5.0-beta.10", "0.5.0-beta.9", false},
+		{"pinned:0.4.2", "0.4.3", "0.4.2", true},
+		{"pinned:0.4.3", "0.4.3", "0.4.3", false},
+	}
+
+	for _, tt := range upgradeTests {
+		if available := isUpgradeAvailable(tt.channel, tt.current, tt.version); available != tt.available {
+			t.Errorf("isUpgradeAvailable(%s, %s, %s) => %t, wanted: %t", tt.channel, tt.current, tt.version, available, tt.available)
+		}
+	}
+}
+
+func TestSaveVersion(t *testing.T) {
+	home, err := ioutil.TempDir("", "akamai-cli-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	os.Setenv("AKAMAI_CLI_HOME", home)
+	defer os.Unsetenv("AKAMAI_CLI_HOME")
+
+	selfPath := filepath.Join(home, "akamai")
+	if err := ioutil.WriteFile(selfPath, []byte("akamai"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	setConfigValue("cli", "keep-versions", "2")
+	defer setConfigValue("cli", "keep-versions", "")
+
+	saved := time.Now().Add(-time.Hour)
+	for _, version := range []string{"0.1.0", "0.2.0", VERSION} {
+		if err := saveVersion(selfPath, version); err != nil {
+			t.Fatalf("saveVersion(%s) => %s", version, err.Error())
+		}
+
+		// Make sure each version is saved after the previous one
+		versionsPath, _ := getAkamaiCliVersionsPath()
+		saved = saved.Add(time.Minute)
+		os.Chtimes(filepath.Join(versionsPath, getVersionBinName(version)), saved, saved)
+	}
+
+	files, _ := getSavedVersions()
+	if len(files) != 2 {
+		t.Errorf("saveVersion() => %d version(s) kept, wanted: 2", len(files))
+	}
+
+	if _, version := getPreviousVersion(); version != "0.2.0" {
+		t.Errorf("getPreviousVersion() => %s, wanted: 0.2.0", version)
+	}
+
+	setConfigValue("cli", "keep-versions", "0")
+	if err := saveVersion(selfPath, VERSION); err != nil {
+		t.Fatalf("saveVersion(%s) => %s", VERSION, err.Error())
+	}
+
+	if path, _ := getPreviousVersion(); path != "" {
+		t.Errorf("getPreviousVersion() => %s, wanted no previous version", path)
+	}
+}
+
+func TestGetReleaseNotes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/releases.atom" {
+			http.NotFound(w, r)
+			return
+		}
+
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry>
+    <link rel="alternate" type="text/html" href="https://github.com/akamai/cli/releases/tag/0.6.0-beta.1"/>
+    <title>0.6.0-beta.1</title>
+    <content type="html">&lt;p&gt;Beta&lt;/p&gt;</content>
+  </entry>
+  <entry>
+    <link rel="alternate" type="text/html" href="https://github.com/akamai/cli/releases/tag/0.5.0"/>
+    <title>Channels</title>
+    <content type="html">&lt;ul&gt;&lt;li&gt;Add &lt;code&gt;--channel&lt;/code&gt;&lt;/li&gt;&lt;li&gt;Fix &amp;amp; improve&lt;/li&gt;&lt;/ul&gt;</content>
+  </entry>
+  <entry>
+    <link rel="alternate" type="text/html" href="https://github.com/akamai/cli/releases/tag/0.4.4"/>
+    <title>0.4.4</title>
+    <content type="html">&lt;p&gt;Bug fixes&lt;/p&gt;</content>
+  </entry>
+  <entry>
+    <link rel="alternate" type="text/html" href="https://github.com/akamai/cli/releases/tag/0.4.3"/>
+    <title>0.4.3</title>
+    <content type="html">&lt;p&gt;Current&lt;/p&gt;</content>
+  </entry>
+</feed>`)
+	}))
+	defer server.Close()
+
+	notesTests := []struct {
+		current  string
+		latest   string
+		versions string
+	}{
+		{"0.4.3", "0.5.0", "0.5.0,0.4.4"},
+		{"0.4.3", "0.4.4", "0.4.4"},
+		{"0.4.3", "0.6.0-beta.1", "0.6.0-beta.1,0.5.0,0.4.4"},
+		{"0.5.0", "0.5.0", ""},
+		{"0.5.0", "0.4.3", ""},
+	}
+
+	for _, tt := range notesTests {
+		notes, err := getReleaseNotes(server.URL+"/releases", tt.current, tt.latest)
+		if err != nil {
+			t.Fatalf("getReleaseNotes(%s, %s) => %s", tt.current, tt.latest, err.Error())
+		}
+
+		var versions []string
+		for _, release := range notes {
+			versions = append(versions, release.Version)
+		}
+
+		if strings.Join(versions, ",") != tt.versions {
+			t.Errorf("getReleaseNotes(%s, %s) => %s, wanted: %s", tt.current, tt.latest, strings.Join(versions, ","), tt.versions)
+		}
+	}
+
+	notes, _ := getReleaseNotes(server.URL+"/releases", "0.4.4", "0.5.0")
+	if len(notes) != 1 || notes[0].Title != "Channels" || notes[0].Notes != "- Add --channel\n- Fix & improve" {
+		t.Errorf("getReleaseNotes(0.4.4, 0.5.0) => %#v, wanted: Channels release", notes)
+	}
+
+	if _, err := getReleaseNotes(server.URL+"/missing", "0.4.3", "0.5.0"); err == nil {
+		t.Errorf("getReleaseNotes(missing) => nil, wanted error")
+	}
+}
+
+func TestValidateConfigValue(t *testing.T) {
+	home, err := ioutil.TempDir("", "akamai-cli-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	os.Setenv("AKAMAI_CLI_HOME", home)
+	defer os.Unsetenv("AKAMAI_CLI_HOME")
+
+	packageDir := filepath.Join(home, ".akamai-cli", "src", "cli-purge")
+	os.MkdirAll(packageDir, 0755)
+	err = ioutil.WriteFile(filepath.Join(packageDir, "cli.json"), []byte(`{
+		"commands": [{"name": "purge"}],
+		"settings": [
+			{"name": "section", "default": "ccu"},
+			{"name": "network", "type": "string", "default": "production"},
+			{"name": "timeout", "type": "int", "default": 30},
+			{"name": "wait", "type": "bool", "default": false}
+		]
+	}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configTests := []struct {
+		key   string
+		value string
+		valid bool
+	}{
+		{"cli.last-upgrade-check", "never", true},
+		{"cli.last-upgrade-check", "ignore", true},
+		{"cli.last-upgrade-check", "2017-10-02T15:04:05Z", true},
+		{"cli.last-upgrade-check", "yesterday", false},
+		{"cli.install-in-path", "no", true},
+		{"cli.install-in-path", "maybe", false},
+		{"cli.config-version", "1", true},
+		{"cli.config-version", "2", false},
+		{"cli.jobs", "8", true},
+		{"cli.jobs", "0", false},
+		{"cli.keep-versions", "0", true},
+		{"cli.keep-versions", "-1", false},
+		{"cli.upgrade-channel", "beta", true},
+		{"cli.upgrade-channel", "pinned:0.4.2", true},
+		{"cli.upgrade-channel", "nightly", false},
+		{"cli.release-url", "https://mirror.example.com/akamai/cli/releases", true},
+		{"cli.release-url", "mirror.example.com", false},
+		{"cli.unknown", "value", false},
+		{"property.section", "default", true},
+		{"purge.section", "default", true},
+		{"purge.timeout", "60", true},
+		{"purge.timeout", "soon", false},
+		{"purge.wait", "true", true},
+		{"purge.wait", "yes", false},
+		{"purge.unknown", "value", false},
+	}
+
+	for _, tt := range configTests {
+		section, key, err := splitConfigKey(tt.key)
+		if err != nil {
+			t.Fatalf("splitConfigKey(%s) => %s", tt.key, err.Error())
+		}
+
+		if err := validateConfigValue(section, key, tt.value); (err == nil) != tt.valid {
+			t.Errorf("validateConfigValue(%s, %s) => %v, wanted valid: %t", tt.key, tt.value, err, tt.valid)
+		}
+	}
+}
+
+func TestValidateConfigEnvName(t *testing.T) {
+	home, err := ioutil.TempDir("", "akamai-cli-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	os.Setenv("AKAMAI_CLI_HOME", home)
+	defer os.Unsetenv("AKAMAI_CLI_HOME")
+	layeredConfig = nil
+	defer func() { layeredConfig = nil }()
+
+	os.MkdirAll(filepath.Join(home, ".akamai-cli"), 0755)
+	err = ioutil.WriteFile(filepath.Join(home, ".akamai-cli", "config"), []byte("[cli]\nconfig-version = 1\nupgrade-notice = true\n\n[a-b]\nc = 1\n\n[property]\ndefault-section = papi\n\n[profile.ci]\nproperty.contract-id = ctr_1\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	envTests := []struct {
+		key   string
+		valid bool
+	}{
+		{"a-b.c", true},
+		{"a-b.d", true},
+		{"a.b-c", false},
+		{"a.b_c", false},
+		{"property.default-section", true},
+		{"property.default.section", false},
+		{"property.DEFAULT_SECTION", false},
+		{"property.contract_id", false},
+		{"property.contract-id", true},
+		{"profile.dev.property.default_section", false},
+		{"profile.dev.property.default-section", true},
+		{"profile.dev.edgerc", true},
+		{"other.default.section", true},
+		{"cli-upgrade.notice", false},
+	}
+
+	for _, tt := range envTests {
+		section, key, err := splitConfigKey(tt.key)
+		if err != nil {
+			t.Fatalf("splitConfigKey(%s) => %s", tt.key, err.Error())
+		}
+
+		if err := validateConfigEnvName(section, key); (err == nil) != tt.valid {
+			t.Errorf("validateConfigEnvName(%s) => %v, wanted valid: %t", tt.key, err, tt.valid)
+		}
+	}
+}
+
+func TestConfigEnvName(t *testing.T) {
+	envTests := []struct {
+		section string
+		key     string
+		name    string
+	}{
+		{"cli", "last-upgrade-check", "AKAMAI_CLI_LAST_UPGRADE_CHECK"},
+		{"cli", "config-version", "AKAMAI_CLI_CONFIG_VERSION"},
+		{"property", "default.section", "AKAMAI_PROPERTY_DEFAULT_SECTION"},
+		{"my-package", "Key2", "AKAMAI_MY_PACKAGE_KEY2"},
+	}
+
+	for _, tt := range envTests {
+		if name := configEnvName(tt.section, tt.key); name != tt.name {
+			t.Errorf("configEnvName(%s, %s) => %s, wanted: %s", tt.section, tt.key, name, tt.name)
+		}
+	}
+}
+
+func TestExportConfigEnv(t *testing.T) {
+	home, err := ioutil.TempDir("", "akamai-cli-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	os.Setenv("AKAMAI_CLI_HOME", home)
+	defer os.Unsetenv("AKAMAI_CLI_HOME")
+
+	os.MkdirAll(filepath.Join(home, ".akamai-cli"), 0755)
+	err = ioutil.WriteFile(filepath.Join(home, ".akamai-cli", "config"), []byte("[cli]\nconfig-version = 1\nlast-upgrade-check = ignore\n\n[property]\nsection = papi\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"AKAMAI_CLI_CONFIG_VERSION", "AKAMAI_CLI_LAST_UPGRADE_CHECK", "AKAMAI_PROPERTY_SECTION", "AKAMAI_PROPERTY_CONTRACT_ID"} {
+		os.Unsetenv(name)
+		defer os.Unsetenv(name)
+	}
+
+	exportConfigEnv()
+
+	envTests := []struct {
+		name  string
+		value string
+	}{
+		{"AKAMAI_CLI_CONFIG_VERSION", "1"},
+		{"AKAMAI_CLI_LAST_UPGRADE_CHECK", "ignore"},
+		{"AKAMAI_PROPERTY_SECTION", ""},
+	}
+
+	for _, tt := range envTests {
+		if value := os.Getenv(tt.name); value != tt.value {
+			t.Errorf("exportConfigEnv() => %s=%s, wanted: %s", tt.name, value, tt.value)
+		}
+	}
+
+	exportPackageConfigEnv(getPackageName("/src/cli-property"), []packageSetting{{Name: "section", Default: "default"}, {Name: "contract-id", Default: "ctr_1"}})
+	if value := os.Getenv("AKAMAI_PROPERTY_SECTION"); value != "papi" {
+		t.Errorf("exportPackageConfigEnv(property) => AKAMAI_PROPERTY_SECTION=%s, wanted: papi", value)
+	}
+
+	if value := os.Getenv("AKAMAI_PROPERTY_CONTRACT_ID"); value != "ctr_1" {
+		t.Errorf("exportPackageConfigEnv(property) => AKAMAI_PROPERTY_CONTRACT_ID=%s, wanted: ctr_1", value)
+	}
+}
+
+func TestPackageSettingDefault(t *testing.T) {
+	settingTests := []struct {
+		json  string
+		value string
+	}{
+		{`{"name": "section"}`, ""},
+		{`{"name": "section", "default": "papi"}`, "papi"},
+		{`{"name": "timeout", "type": "int", "default": 30}`, "30"},
+		{`{"name": "wait", "type": "bool", "default": true}`, "true"},
+	}
+
+	for _, tt := range settingTests {
+		var setting packageSetting
+		if err := json.Unmarshal([]byte(tt.json), &setting); err != nil {
+			t.Fatalf("json.Unmarshal(%s) => %s", tt.json, err.Error())
+		}
+
+		if value := setting.defaultValue(); value != tt.value {
+			t.Errorf("defaultValue(%s) => %s, wanted: %s", tt.json, value, tt.value)
+		}
+	}
+}
+
+func TestProjectConfig(t *testing.T) {
+	home, err := ioutil.TempDir("", "akamai-cli-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	os.Setenv("AKAMAI_CLI_HOME", home)
+	defer os.Unsetenv("AKAMAI_CLI_HOME")
+
+	os.MkdirAll(filepath.Join(home, ".akamai-cli"), 0755)
+	configPath := filepath.Join(home, ".akamai-cli", "config")
+	err = ioutil.WriteFile(configPath, []byte("[cli]\nconfig-version = 1\njobs = 2\n\n[property]\nsection = papi\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	project := filepath.Join(home, "project")
+	os.MkdirAll(filepath.Join(project, "src", "nested"), 0755)
+	err = ioutil.WriteFile(filepath.Join(project, projectManifestName), []byte(`{"packages": ["property@v1.0.0"], "config": {"cli": {"jobs": 8, "release-url": "https://example.org"}, "profile.ci": {"edgerc": "/tmp/edgerc"}, "property": {"section": "staging"}}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	os.Chdir(filepath.Join(project, "src", "nested"))
+
+	projectManifestLoaded = false
+	layeredConfig = nil
+	defer func() { projectManifestLoaded = false; projectManifestCache = nil; layeredConfig = nil }()
+
+	manifest, path, err := getProjectManifest()
+	if err != nil || manifest == nil {
+		t.Fatalf("getProjectManifest() => %v, %v", manifest, err)
+	}
+
+	if resolved, _ := filepath.EvalSymlinks(filepath.Join(project, projectManifestName)); path != filepath.Join(project, projectManifestName) && path != resolved {
+		t.Errorf("getProjectManifest() => %s, wanted: %s", path, filepath.Join(project, projectManifestName))
+	}
+
+	if len(manifest.Packages) != 1 || manifest.Packages[0] != "property@v1.0.0" {
+		t.Errorf("getProjectManifest() => %v, wanted: [property@v1.0.0]", manifest.Packages)
+	}
+
+	configTests := []struct {
+		section string
+		key     string
+		value   string
+	}{
+		{"cli", "config-version", "1"},
+		{"cli", "jobs", "2"},
+		{"cli", "release-url", ""},
+		{"profile.ci", "edgerc", ""},
+		{"property", "section", "staging"},
+	}
+
+	for _, tt := range configTests {
+		if value := getConfigValue(tt.section, tt.key); value != tt.value {
+			t.Errorf("getConfigValue(%s, %s) => %s, wanted: %s", tt.section, tt.key, value, tt.value)
+		}
+	}
+
+	if ignored := getIgnoredProjectConfig(); strings.Join(ignored, ", ") != "cli.jobs, cli.release-url, profile.ci.edgerc" {
+		t.Errorf("getIgnoredProjectConfig() => %s, wanted: cli.jobs, cli.release-url, profile.ci.edgerc", strings.Join(ignored, ", "))
+	}
+
+	setConfigValue("cli", "keep-versions", "5")
+	if err := saveConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(data), "staging") || strings.Contains(string(data), "8") || !strings.Contains(string(data), "keep-versions") {
+		t.Errorf("saveConfig() => %s, wanted project config to be left out", string(data))
+	}
+
+	if value := getConfigValue("cli", "keep-versions"); value != "5" {
+		t.Errorf("getConfigValue(cli, keep-versions) => %s, wanted: 5", value)
+	}
+}
+
+func TestSplitConfigKey(t *testing.T) {
+	keyTests := []struct {
+		name    string
+		section string
+		key     string
+		err     bool
+	}{
+		{"cli.jobs", "cli", "jobs", false},
+		{"property.contract-id", "property", "contract-id", false},
+		{"profile.acme.edgerc", "profile.acme", "edgerc", false},
+		{"profile.acme.property.section", "profile.acme", "property.section", false},
+		{"profile.acme", "", "", true},
+		{"jobs", "", "", true},
+	}
+
+	for _, tt := range keyTests {
+		section, key, err := splitConfigKey(tt.name)
+		if (err != nil) != tt.err || section != tt.section || key != tt.key {
+			t.Errorf("splitConfigKey(%s) => %s, %s, wanted: %s, %s", tt.name, section, key, tt.section, tt.key)
+		}
+	}
+}
+
+func TestProfileConfig(t *testing.T) {
+	home, err := ioutil.TempDir("", "akamai-cli-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	os.Setenv("AKAMAI_CLI_HOME", home)
+	defer os.Unsetenv("AKAMAI_CLI_HOME")
+
+	os.MkdirAll(filepath.Join(home, ".akamai-cli"), 0755)
+	err = ioutil.WriteFile(filepath.Join(home, ".akamai-cli", "config"), []byte("[cli]\nconfig-version = 1\n\n[property]\nsection = papi\n\n[profile.acme]\nedgerc = /tmp/acme.edgerc\nsection = acme\nproperty.section = staging\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{profileEnv, "AKAMAI_EDGERC", "AKAMAI_SECTION", "AKAMAI_ACCOUNT_KEY"} {
+		os.Unsetenv(name)
+		defer os.Unsetenv(name)
+	}
+	layeredConfig = nil
+	defer func() { layeredConfig = nil }()
+
+	if err := setProfile("missing"); err == nil {
+		t.Errorf("setProfile(missing) => nil, wanted an error")
+	}
+
+	if value := getConfigValue("property", "section"); value != "papi" {
+		t.Errorf("getConfigValue(property, section) => %s, wanted: papi", value)
+	}
+
+	if err := setProfile("acme"); err != nil {
+		t.Fatal(err)
+	}
+
+	if value := getConfigValue("property", "section"); value != "staging" {
+		t.Errorf("getConfigValue(property, section) => %s, wanted: staging", value)
+	}
+
+	exportCredentialsEnv()
+	envTests := []struct {
+		name  string
+		value string
+	}{
+		{"AKAMAI_EDGERC", "/tmp/acme.edgerc"},
+		{"AKAMAI_SECTION", "acme"},
+		{"AKAMAI_ACCOUNT_KEY", ""},
+	}
+
+	for _, tt := range envTests {
+		if value := os.Getenv(tt.name); value != tt.value {
+			t.Errorf("exportCredentialsEnv() => %s=%s, wanted: %s", tt.name, value, tt.value)
+		}
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	home, err := ioutil.TempDir("", "akamai-cli-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	os.Setenv("AKAMAI_CLI_HOME", home)
+	defer os.Unsetenv("AKAMAI_CLI_HOME")
+
+	edgerc := filepath.Join(home, ".edgerc")
+	err = ioutil.WriteFile(edgerc, []byte("[default]\nhost = akab-default.luna.akamaiapis.net\n\n[papi]\nhost = akab-papi.luna.akamaiapis.net\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Unsetenv(profileEnv)
+	defer func() { globalCredentials = make(map[string]string) }()
+
+	credentialTests := []struct {
+		edgerc  string
+		section string
+		verify  bool
+		err     bool
+	}{
+		{edgerc, "", true, false},
+		{edgerc, "papi", true, false},
+		{edgerc, "missing", true, true},
+		{filepath.Join(home, "missing"), "", true, true},
+		{edgerc, "missing", false, false},
+		{filepath.Join(home, "missing"), "new", false, false},
+	}
+
+	for _, tt := range credentialTests {
+		globalCredentials = make(map[string]string)
+		if err := setCredentials(tt.edgerc, tt.section, "", tt.verify); (err != nil) != tt.err {
+			t.Errorf("setCredentials(%s, %s, verify: %t) => %v, wanted error: %t", tt.edgerc, tt.section, tt.verify, err, tt.err)
+		}
+
+		if getCredentials()["section"] != tt.section {
+			t.Errorf("setCredentials(%s, %s, verify: %t) => section %s, wanted: %s", tt.edgerc, tt.section, tt.verify, getCredentials()["section"], tt.section)
+		}
+	}
+
+	globalCredentials = make(map[string]string)
+	if err := setCredentials(edgerc, "papi", "1-ABCDE", true); err != nil {
+		t.Fatal(err)
+	}
+
+	argTests := []struct {
+		flags []string
+		args  string
+	}{
+		{nil, ""},
+		{[]string{"section"}, "--section papi"},
+		{[]string{"accountkey", "edgerc"}, "--edgerc " + edgerc + " --accountkey 1-ABCDE"},
+	}
+
+	for _, tt := range argTests {
+		if args := strings.Join(getCredentialArgs(Command{CredentialFlags: tt.flags}), " "); args != tt.args {
+			t.Errorf("getCredentialArgs(%v) => %s, wanted: %s", tt.flags, args, tt.args)
+		}
+	}
+}
+
+func TestParseCredentialExport(t *testing.T) {
+	exportTests := []struct {
+		data string
+		host string
+		err  bool
+	}{
+		{"client_secret = c2VjcmV0=\nhost = akab-abc-def.luna.akamaiapis.net\naccess_token = akab-access\nclient_token = akab-client\n", "akab-abc-def.luna.akamaiapis.net", false},
+		{"[default]\nclient_secret\tc2VjcmV0=\nhost\thttps://akab-abc-def.luna.akamaiapis.net/\naccess_token\takab-access\nclient_token\takab-client\n", "akab-abc-def.luna.akamaiapis.net", false},
+		{"client_secret: c2VjcmV0=\r\nhost: akab-abc-def.luna.akamaiapis.net\r\naccess_token: akab-access\r\nclient_token: akab-client\r\n", "akab-abc-def.luna.akamaiapis.net", false},
+		{"client_secret = c2VjcmV0=\nhost = akab-abc-def.luna.akamaiapis.net\n", "", true},
+	}
+
+	for _, tt := range exportTests {
+		credentials, err := parseCredentialExport(tt.data)
+		if (err != nil) != tt.err {
+			t.Errorf("parseCredentialExport(%q) => %v, wanted error: %t", tt.data, err, tt.err)
+			continue
+		}
+
+		if err == nil && (credentials["host"] != tt.host || credentials["client_secret"] != "c2VjcmV0=") {
+			t.Errorf("parseCredentialExport(%q) => %v, wanted host: %s", tt.data, credentials, tt.host)
+		}
+	}
+}
+
+func TestVerifyEdgercSection(t *testing.T) {
+	edgerc, err := ini.Load([]byte(`[default]
+client_secret = c2VjcmV0=
+host = akab-abc-def.luna.akamaiapis.net
+access_token = akab-access
+client_token = akab-client
+
+[scheme]
+client_secret = c2VjcmV0=
+host = https://akab-abc-def.luna.akamaiapis.net/
+access_token = akab-access
+client_token = akab-client
+
+[missing]
+host = akab-abc-def.luna.akamaiapis.net
+max-body = big
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sectionTests := []struct {
+		section  string
+		problems int
+	}{
+		{"default", 0},
+		{"scheme", 1},
+		{"missing", 4},
+	}
+
+	for _, tt := range sectionTests {
+		if problems := verifyEdgercSection(edgerc.Section(tt.section)); len(problems) != tt.problems {
+			t.Errorf("verifyEdgercSection(%s) => %v, wanted %d problem(s)", tt.section, problems, tt.problems)
+		}
+	}
+
+	if edgerc.Section("default").HasKey("max-body") {
+		t.Errorf("verifyEdgercSection(default) added max-body")
+	}
+
+	if value := redactSecret("akab-c113ntt0k3n4qtari252bfxxbsl-yvsdj"); value != "********vsdj" {
+		t.Errorf("redactSecret(akab-c113ntt0k3n4qtari252bfxxbsl-yvsdj) => %s, wanted: ********vsdj", value)
+	}
+}
+
+func TestSendEdgegridRequest(t *testing.T) {
+	secret := "c2VjcmV0LWtleS1mb3ItdGVzdGluZy1vbmx5LTEyMzQ1Ng=="
+	body := `{"objects": ["https://example.com/"]}`
+
+	sign := func(key string, data string) string {
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write([]byte(data))
+		return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		i := strings.Index(auth, "signature=")
+		if !strings.HasPrefix(auth, "EG1-HMAC-SHA256 client_token=akab-client;access_token=akab-access;timestamp=") || i == -1 {
+			t.Errorf("Authorization => %s, wanted EdgeGrid v1 header", auth)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var timestamp string
+		for _, field := range strings.Split(auth[:i], ";") {
+			if strings.HasPrefix(field, "timestamp=") {
+				timestamp = strings.TrimPrefix(field, "timestamp=")
+			}
+		}
+
+		received, _ := ioutil.ReadAll(r.Body)
+		contentHash := ""
+		if r.Method == http.MethodPost && len(received) > 0 {
+			hash := sha256.Sum256(received)
+			contentHash = base64.StdEncoding.EncodeToString(hash[:])
+		}
+
+		data := strings.Join([]string{r.Method, "https", r.Host, r.URL.RequestURI(), "", contentHash, auth[:i]}, "\t")
+		if signature := sign(sign(secret, timestamp), data); auth[i+len("signature="):] != signature {
+			t.Errorf("Authorization => %s, wanted signature: %s", auth, signature)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"method":"%s","path":"%s","query":"%s","header":"%s","body":%q}`, r.Method, r.URL.Path, r.URL.RawQuery, r.Header.Get("X-Test"), string(received))
+	}))
+	defer server.Close()
+
+	credentials := edgegridCredentials{
+		ClientSecret: secret,
+		Host:         strings.TrimPrefix(server.URL, "https://"),
+		AccessToken:  "akab-access",
+		ClientToken:  "akab-client",
+		MaxBody:      defaultEdgegridMaxBody,
+	}
+
+	requestTests := []struct {
+		request httpRequest
+		output  string
+	}{
+		{
+			httpRequest{Method: "GET", Path: "/papi/v1/groups?a=1", Query: url.Values{"b": {"2"}}, Headers: http.Header{"X-Test": {"yes"}}},
+			"{\n  \"method\": \"GET\",\n  \"path\": \"/papi/v1/groups\",\n  \"query\": \"a=1&b=2\",\n  \"header\": \"yes\",\n  \"body\": \"\"\n}\n",
+		},
+		{
+			httpRequest{Method: "POST", Path: "ccu/v3/invalidate/url", Query: url.Values{}, Headers: http.Header{"Content-Type": {"application/json"}}, Body: []byte(body)},
+			"{\n  \"method\": \"POST\",\n  \"path\": \"/ccu/v3/invalidate/url\",\n  \"query\": \"\",\n  \"header\": \"\",\n  \"body\": \"{\\\"objects\\\": [\\\"https://example.com/\\\"]}\"\n}\n",
+		},
+	}
+
+	for _, tt := range requestTests {
+		resp, err := sendEdgegridRequest(server.Client(), credentials, tt.request)
+		if err != nil {
+			t.Fatalf("sendEdgegridRequest(%s %s) => %s", tt.request.Method, tt.request.Path, err.Error())
+		}
+
+		var output bytes.Buffer
+		err = printHTTPResponse(&output, resp, false, true)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if output.String() != tt.output {
+			t.Errorf("sendEdgegridRequest(%s %s) => %s, wanted: %s", tt.request.Method, tt.request.Path, output.String(), tt.output)
+		}
+	}
+}
+
+func TestGetJobs(t *testing.T) {
+	home, err := ioutil.TempDir("", "akamai-cli-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	os.Setenv("AKAMAI_CLI_HOME", home)
+	defer os.Unsetenv("AKAMAI_CLI_HOME")
+
+	jobsTests := []struct {
+		flag   int
+		config string
+		jobs   int
+	}{
+		{0, "", 4},
+		{0, "3", 3},
+		{0, "invalid", 4},
+		{0, "1", 1},
+		{6, "3", 6},
+		{6, "", 6},
+		{1, "", 1},
+	}
+
+	for _, tt := range jobsTests {
+		set := flag.NewFlagSet("install", flag.ContinueOnError)
+		set.Int("jobs", 0, "")
+		set.Parse([]string{"--jobs", strconv.Itoa(tt.flag)})
+
+		unsetConfigValue("cli", "jobs")
+		if tt.config != "" {
+			setConfigValue("cli", "jobs", tt.config)
+		}
+
+		if jobs := getJobs(cli.NewContext(nil, set, nil)); jobs != tt.jobs {
+			t.Errorf("getJobs(--jobs %d, cli.jobs = %s) => %d, wanted: %d", tt.flag, tt.config, jobs, tt.jobs)
+		}
+	}
+}
+
+func TestRunParallelJobs(t *testing.T) {
+	var output bytes.Buffer
+	results := runParallelJobs(2, []string{"install", "--force"}, []string{"one", "fail-two", "three"}, &output)
+
+	resultTests := []struct {
+		name   string
+		failed bool
+	}{
+		{"one", false},
+		{"fail-two", true},
+		{"three", false},
+	}
+
+	if len(results) != len(resultTests) {
+		t.Fatalf("runParallelJobs() => %d results, wanted: %d", len(results), len(resultTests))
+	}
+
+	for i, tt := range resultTests {
+		if results[i].name != tt.name || (results[i].err != nil) != tt.failed {
+			t.Errorf("runParallelJobs()[%d] => %s, %v, wanted: %s, failed: %t", i, results[i].name, results[i].err, tt.name, tt.failed)
+		}
+
+		if !strings.Contains(output.String(), "==> "+tt.name+"\ninstall --force "+tt.name+"\n") {
+			t.Errorf("runParallelJobs() => %s, wanted output for %s", output.String(), tt.name)
+		}
+	}
+}
+
+func TestSummarizeJobs(t *testing.T) {
+	summaryTests := []struct {
+		results []jobResult
+		lines   []string
+		err     bool
+	}{
+		{
+			[]jobResult{{name: "one", duration: 1200 * time.Millisecond}, {name: "two", duration: 40 * time.Millisecond}},
+			[]string{"  Package  Result  Time", "  one      OK      1.2s", "  two      OK      0s"},
+			false,
+		},
+		{
+			[]jobResult{{name: "one"}, {name: "broken", err: errors.New("exit status 1")}},
+			[]string{"  one      OK      0s", "  broken   FAIL    0s"},
+			true,
+		},
+	}
+
+	for _, tt := range summaryTests {
+		var output bytes.Buffer
+		err := summarizeJobs(&output, tt.results)
+		if (err != nil) != tt.err {
+			t.Errorf("summarizeJobs(%v) => %v, wanted error: %t", tt.results, err, tt.err)
+		}
+
+		for _, line := range tt.lines {
+			if !strings.Contains(output.String(), line+"\n") {
+				t.Errorf("summarizeJobs(%v) => %s, wanted line: %s", tt.results, output.String(), line)
+			}
+		}
+	}
+}
+
+func TestUpdateConfig(t *testing.T) {
+	home, err := ioutil.TempDir("", "akamai-cli-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	os.Setenv("AKAMAI_CLI_HOME", home)
+	defer os.Unsetenv("AKAMAI_CLI_HOME")
+
+	os.MkdirAll(filepath.Join(home, ".akamai-cli"), 0755)
+	configPath := filepath.Join(home, ".akamai-cli", "config")
+	err = ioutil.WriteFile(configPath, []byte("[cli]\nconfig-version = 1\nlatest-version = 1.0.0\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if value := getConfigValue("cli", "latest-version"); value != "1.0.0" {
+		t.Fatalf("getConfigValue(cli, latest-version) => %s, wanted: 1.0.0", value)
+	}
+
+	// Another process finds a newer version after the config was read
+	err = ioutil.WriteFile(configPath, []byte("[cli]\nconfig-version = 1\nlatest-version = 1.1.0\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = updateConfig(func() {
+		setConfigValue("cli", "last-upgrade-check", "ignore")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(data), "1.1.0") || !strings.Contains(string(data), "ignore") {
+		t.Errorf("updateConfig() => %s, wanted latest-version = 1.1.0 and last-upgrade-check = ignore", string(data))
+	}
+
+	if _, err := os.Stat(filepath.Join(home, ".akamai-cli", ".config.lock")); !os.IsNotExist(err) {
+		t.Errorf("updateConfig() left the lock file behind")
+	}
+}
+
+// commitTestFiles writes the files to the work tree of repo and commits them
+func commitTestFiles(t *testing.T, repo *git.Repository, files map[string]string) plumbing.Hash {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(worktree.Filesystem.Root(), name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := worktree.Add(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hash, err := worktree.Commit("test commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.org", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return hash
+}
+
+func TestLockfileRoundTrip(t *testing.T) {
+	home, err := ioutil.TempDir("", "akamai-cli-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	os.Setenv("AKAMAI_CLI_HOME", home)
+	defer os.Unsetenv("AKAMAI_CLI_HOME")
+
+	packageDir := filepath.Join(home, ".akamai-cli", "src", "cli-foo")
+	repo, err := git.PlainInit(packageDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash := commitTestFiles(t, repo, map[string]string{
+		"cli.json": `{"commands": [{"name": "foo", "version": "1.2.3", "bin": "https://example.org/foo"}]}`,
+	})
+
+	_, err = repo.CreateRemote(&gitconfig.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{"https://github.com/akamai/cli-foo.git"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = setPackageState(packageDir, packageState{
+		Repo:     "https://github.com/akamai/cli-foo.git",
+		Ref:      "v1",
+		Strategy: "tag",
+		Method:   installMethodBinary,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := lockfile{
+		Version: lockfileVersion,
+		Packages: []lockedPackage{
+			{
+				Name:     "cli-foo",
+				Repo:     "https://github.com/akamai/cli-foo.git",
+				Ref:      "v1",
+				Commit:   hash.String(),
+				Version:  "1.2.3",
+				Method:   installMethodBinary,
+				Strategy: "tag",
+			},
+		},
+	}
+
+	lock := buildLockfile()
+	if !reflect.DeepEqual(lock, expected) {
+		t.Errorf("buildLockfile() => %+v, wanted: %+v", lock, expected)
+	}
+
+	path := filepath.Join(home, "packages.lock")
+	if err := writeLockfile(path, lock); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := readLockfile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(read, expected) {
+		t.Errorf("readLockfile(writeLockfile(%+v)) => %+v", expected, read)
+	}
+}
+
+// newTestCommit stores a commit with an empty tree in repo
+func newTestCommit(t *testing.T, repo *git.Repository, message string, parents ...plumbing.Hash) plumbing.Hash {
+	tree := repo.Storer.NewEncodedObject()
+	if err := (&object.Tree{}).Encode(tree); err != nil {
+		t.Fatal(err)
+	}
+	treeHash, err := repo.Storer.SetEncodedObject(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signature := object.Signature{Name: "Test", Email: "test@example.org", When: time.Unix(1500000000, 0)}
+	commit := &object.Commit{
+		Author:       signature,
+		Committer:    signature,
+		Message:      message,
+		TreeHash:     treeHash,
+		ParentHashes: parents,
+	}
+
+	obj := repo.Storer.NewEncodedObject()
+	if err := commit.Encode(obj); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return hash
+}
+
+// newTestTag stores an annotated tag of the commit in repo, and returns the hash of the tag object
+func newTestTag(t *testing.T, repo *git.Repository, name string, commit plumbing.Hash) plumbing.Hash {
+	tag := &object.Tag{
+		Name:       name,
+		Tagger:     object.Signature{Name: "Test", Email: "test@example.org", When: time.Unix(1500000000, 0)},
+		Message:    name,
+		TargetType: plumbing.CommitObject,
+		Target:     commit,
+	}
+
+	obj := repo.Storer.NewEncodedObject()
+	if err := tag.Encode(obj); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return hash
+}
+
+// newTestRefRepo returns an in-memory repository with four commits, tagged and branched as follows:
+//
+//	commits[0] tags v1.0.0, release
+//	commits[1] tag v1.9.0, branches release, local (refs/heads)
+//	commits[2] tag v1.10.0 (annotated), branch master
+//	commits[3] tags v2.0.0-beta.1, latest, branch feature/foo
+func newTestRefRepo(t *testing.T) (*git.Repository, []plumbing.Hash) {
+	repo, err := git.Init(memory.NewStorage(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var commits []plumbing.Hash
+	commits = append(commits, newTestCommit(t, repo, "one"))
+	commits = append(commits, newTestCommit(t, repo, "two", commits[0]))
+	commits = append(commits, newTestCommit(t, repo, "three", commits[1]))
+	commits = append(commits, newTestCommit(t, repo, "four", commits[2]))
+
+	refs := map[string]plumbing.Hash{
+		"refs/tags/v1.0.0":                commits[0],
+		"refs/tags/release":               commits[0],
+		"refs/tags/v1.9.0":                commits[1],
+		"refs/remotes/origin/release":     commits[1],
+		"refs/heads/local":                commits[1],
+		"refs/tags/v1.10.0":               newTestTag(t, repo, "v1.10.0", commits[2]),
+		"refs/remotes/origin/master":      commits[2],
+		"refs/tags/v2.0.0-beta.1":         commits[3],
+		"refs/tags/latest":                commits[3],
+		"refs/remotes/origin/feature/foo": commits[3],
+	}
+
+	for name, hash := range refs {
+		if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.ReferenceName(name), hash)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return repo, commits
+}
+
+func TestResolveRef(t *testing.T) {
+	repo, commits := newTestRefRepo(t)
+
+	refTests := []struct {
+		ref    string
+		commit plumbing.Hash
+	}{
+		{"v1.0.0", commits[0]},
+		{"v1.10.0", commits[2]},
+		{"latest", commits[3]},
+		{"release", commits[0]},
+		{"feature/foo", commits[3]},
+		{"master", commits[2]},
+		{"local", commits[1]},
+		{commits[1].String(), commits[1]},
+		{commits[1].String()[:7], commits[1]},
+		{strings.ToUpper(commits[3].String()[:10]), commits[3]},
+		{commits[2].String()[:4], commits[2]},
+		{commits[2].String()[:3], plumbing.ZeroHash},
+		{"v3.0.0", plumbing.ZeroHash},
+		{"deadbeef", plumbing.ZeroHash},
+		{"", plumbing.ZeroHash},
+	}
+
+	for _, tt := range refTests {
+		commit, err := resolveRef(repo, tt.ref)
+		if commit != tt.commit || (err == nil) != !tt.commit.IsZero() {
+			t.Errorf("resolveRef(%s) => (%s, %v), wanted: %s", tt.ref, commit, err, tt.commit)
+		}
+	}
+}
+
+func TestInstallRef(t *testing.T) {
+	repo, commits := newTestRefRepo(t)
+
+	installTests := []struct {
+		input  string
+		repo   string
+		commit plumbing.Hash
+	}{
+		{"foo@v1.10.0", "https://github.com/akamai/cli-foo.git", commits[2]},
+		{"akamai/cli-foo@feature/foo", "https://github.com/akamai/cli-foo.git", commits[3]},
+		{"git@github.com:akamai/cli-foo.git@" + commits[1].String()[:7], "git@github.com:akamai/cli-foo.git", commits[1]},
+		{"https://github.com/akamai/cli-foo.git@release", "https://github.com/akamai/cli-foo.git", commits[0]},
+		{"foo@v3.0.0", "https://github.com/akamai/cli-foo.git", plumbing.ZeroHash},
+	}
+
+	for _, tt := range installTests {
+		name, ref := splitRepoRef(tt.input)
+		if githubize(name) != tt.repo {
+			t.Errorf("githubize(splitRepoRef(%s)) => %s, wanted: %s", tt.input, githubize(name), tt.repo)
+		}
+
+		commit, err := resolveRef(repo, ref)
+		if commit != tt.commit || (err == nil) != !tt.commit.IsZero() {
+			t.Errorf("resolveRef(splitRepoRef(%s)) => (%s, %v), wanted: %s", tt.input, commit, err, tt.commit)
+		}
+	}
+}
+
+func TestResolveUpdateTarget(t *testing.T) {
+	repo, commits := newTestRefRepo(t)
+
+	updateTests := []struct {
+		state  packageState
+		commit plumbing.Hash
+	}{
+		{packageState{}, commits[2]},
+		{packageState{Strategy: updateStrategyHead}, commits[2]},
+		{packageState{Strategy: updateStrategyTag}, commits[2]},
+		{packageState{Ref: "v1.0.0"}, commits[0]},
+		{packageState{Ref: "feature/foo", Strategy: updateStrategyTag}, commits[3]},
+		{packageState{Ref: "v3.0.0"}, plumbing.ZeroHash},
+	}
+
+	for _, tt := range updateTests {
+		commit, err := resolveUpdateTarget(repo, tt.state)
+		if commit != tt.commit || (err == nil) != !tt.commit.IsZero() {
+			t.Errorf("resolveUpdateTarget(%+v) => (%s, %v), wanted: %s", tt.state, commit, err, tt.commit)
+		}
+	}
+}
+
+func TestLatestSemverTag(t *testing.T) {
+	repo, _ := newTestRefRepo(t)
+	if tag, err := latestSemverTag(repo); tag != "v1.10.0" || err != nil {
+		t.Errorf("latestSemverTag() => (%s, %v), wanted: v1.10.0", tag, err)
+	}
+
+	tagTests := []struct {
+		tags   []string
+		latest string
+	}{
+		{[]string{"1.2", "v1.2.1", "v1.2.0"}, "v1.2.1"},
+		{[]string{"v0.9", "0.10", "v0.9.9"}, "0.10"},
+		{[]string{"2", "v1.99.99"}, "2"},
+		{[]string{"v1.0.0", "v2.0.0-beta.1", "v2.0.0.1", "release-2"}, "v1.0.0"},
+		{[]string{"latest", "stable"}, ""},
+		{[]string{}, ""},
+	}
+
+	for _, tt := range tagTests {
+		repo, err := git.Init(memory.NewStorage(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		commit := newTestCommit(t, repo, "one")
+		for _, name := range tt.tags {
+			repo.Storer.SetReference(plumbing.NewHashReference(plumbing.ReferenceName("refs/tags/"+name), commit))
+		}
+
+		tag, err := latestSemverTag(repo)
+		if tag != tt.latest || (err == nil) != (tt.latest != "") {
+			t.Errorf("latestSemverTag(%s) => (%s, %v), wanted: %s", strings.Join(tt.tags, ", "), tag, err, tt.latest)
+		}
+	}
+}
+
+func TestPackageHistory(t *testing.T) {
+	state := packageState{}
+	if commit := state.popHistory(); commit != "" || len(state.History) != 0 {
+		t.Errorf("popHistory() => %s, wanted: empty history", commit)
+	}
+
+	for i := 1; i <= maxPackageHistory+2; i++ {
+		state.pushHistory(strconv.Itoa(i))
+	}
+
+	if len(state.History) != maxPackageHistory || state.History[0] != "3" {
+		t.Errorf("pushHistory(1..%d) => %s, wanted: 3..%d", maxPackageHistory+2, strings.Join(state.History, ","), maxPackageHistory+2)
+	}
+
+	for i := maxPackageHistory + 2; i >= 3; i-- {
+		if commit := state.popHistory(); commit != strconv.Itoa(i) {
+			t.Errorf("popHistory() => %s, wanted: %d", commit, i)
+		}
+	}
+
+	if commit := state.popHistory(); commit != "" || len(state.History) != 0 {
+		t.Errorf("popHistory() => %s, wanted: empty history", commit)
+	}
+}
+
+func TestCommitStagingDir(t *testing.T) {
+	root, err := ioutil.TempDir("", "akamai-cli-staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	packageDir := filepath.Join(root, "src", "cli-foo")
+	newStaged := func(content string) string {
+		tmpDir, err := ioutil.TempDir(root, "cli-foo-")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		stagingDir := filepath.Join(tmpDir, "cli-foo")
+		os.MkdirAll(stagingDir, 0755)
+		ioutil.WriteFile(filepath.Join(stagingDir, "cli.json"), []byte(content), 0644)
+		return stagingDir
+	}
+
+	commitTests := []struct {
+		stagingDir string
+		content    string
+		err        bool
+	}{
+		{newStaged("one"), "one", false},
+		{newStaged("two"), "two", false},
+		// The staged package is missing, so the existing package must be restored from "previous"
+		{filepath.Join(root, "missing", "cli-foo"), "two", true},
+	}
+
+	os.MkdirAll(filepath.Dir(packageDir), 0755)
+	os.MkdirAll(filepath.Join(root, "missing"), 0755)
+	for _, tt := range commitTests {
+		err := commitStagingDir(tt.stagingDir, packageDir)
+		if (err != nil) != tt.err {
+			t.Errorf("commitStagingDir(%s) => %v, wanted error: %t", tt.stagingDir, err, tt.err)
+		}
+
+		if content, _ := ioutil.ReadFile(filepath.Join(packageDir, "cli.json")); string(content) != tt.content {
+			t.Errorf("commitStagingDir(%s) => package contains %s, wanted: %s", tt.stagingDir, content, tt.content)
+		}
+
+		if _, err := os.Stat(filepath.Dir(tt.stagingDir)); !tt.err && !os.IsNotExist(err) {
+			t.Errorf("commitStagingDir(%s) => staging directory was not removed", tt.stagingDir)
+		}
+
+		if _, err := os.Stat(filepath.Join(filepath.Dir(tt.stagingDir), "previous")); !os.IsNotExist(err) {
+			t.Errorf("commitStagingDir(%s) => previous package was not moved back or removed", tt.stagingDir)
+		}
+	}
+}
+
+func TestRemoveStaleStagingDirs(t *testing.T) {
+	stagingPath, err := ioutil.TempDir("", "akamai-cli-staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(stagingPath)
+
+	staleTests := []struct {
+		name    string
+		age     time.Duration
+		removed bool
+	}{
+		{"cli-new-1", 0, false},
+		{"cli-recent-2", 23 * time.Hour, false},
+		{"cli-stale-3", 25 * time.Hour, true},
+		{"cli-ancient-4", 30 * 24 * time.Hour, true},
+	}
+
+	for _, tt := range staleTests {
+		dir := filepath.Join(stagingPath, tt.name)
+		os.MkdirAll(filepath.Join(dir, "cli-foo"), 0755)
+		modified := time.Now().Add(-tt.age)
+		if err := os.Chtimes(dir, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removeStaleStagingDirs(stagingPath)
+
+	for _, tt := range staleTests {
+		_, err := os.Stat(filepath.Join(stagingPath, tt.name))
+		if os.IsNotExist(err) != tt.removed {
+			t.Errorf("removeStaleStagingDirs() => %s removed: %t, wanted: %t", tt.name, os.IsNotExist(err), tt.removed)
+		}
+	}
+}
+
+func TestCopyDir(t *testing.T) {
+	root, err := ioutil.TempDir("", "akamai-cli-copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	src := filepath.Join(root, "src")
+	dst := filepath.Join(root, "dst")
+	os.MkdirAll(filepath.Join(src, "bin"), 0755)
+	os.MkdirAll(filepath.Join(src, ".git", "refs"), 0700)
+
+	files := []struct {
+		name string
+		mode os.FileMode
+	}{
+		{"cli.json", 0644},
+		{filepath.Join("bin", "akamai-foo"), 0755},
+		{filepath.Join(".git", "refs", "secret"), 0600},
+	}
+
+	for _, file := range files {
+		if err := ioutil.WriteFile(filepath.Join(src, file.name), []byte(file.name), file.mode); err != nil {
+			t.Fatal(err)
+		}
+		os.Chmod(filepath.Join(src, file.name), file.mode)
+	}
+
+	links := map[string]string{
+		"akamai-foo":                     filepath.Join("bin", "akamai-foo"),
+		filepath.Join("bin", "dangling"): "missing",
+	}
+
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(src, name)); err != nil {
+			t.Skip("symlinks are not supported: " + err.Error())
+		}
+	}
+
+	if err := copyDir(src, dst); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range files {
+		info, err := os.Lstat(filepath.Join(dst, file.name))
+		if err != nil {
+			t.Errorf("copyDir() => %s not copied: %s", file.name, err.Error())
+			continue
+		}
+
+		if info.Mode() != file.mode {
+			t.Errorf("copyDir() => %s has mode %s, wanted: %s", file.name, info.Mode(), file.mode)
+		}
+
+		if content, _ := ioutil.ReadFile(filepath.Join(dst, file.name)); string(content) != file.name {
+			t.Errorf("copyDir() => %s contains %s, wanted: %s", file.name, content, file.name)
+		}
+	}
+
+	for name, target := range links {
+		if link, err := os.Readlink(filepath.Join(dst, name)); err != nil || link != target {
+			t.Errorf("copyDir() => %s links to %s, wanted: %s", name, link, target)
+		}
+	}
+
+	if info, err := os.Stat(filepath.Join(dst, ".git", "refs")); err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("copyDir() => .git/refs not copied with mode 0700")
+	}
+}
+
+func TestGetInstallMethod(t *testing.T) {
+	packageDir := filepath.Join("home", ".akamai-cli", "src", "cli-foo")
+
+	methodTests := []struct {
+		executable []string
+		state      packageState
+		method     string
+	}{
+		{[]string{filepath.Join(packageDir, "bin", "akamai-foo")}, packageState{Link: packageDir}, "link"},
+		{[]string{"node", filepath.Join(packageDir, "akamai-foo")}, packageState{Link: packageDir, Method: installMethodSource}, "link"},
+		{[]string{filepath.Join(packageDir, "bin", "akamai-foo")}, packageState{Method: installMethodSource}, installMethodSource},
+		{[]string{filepath.Join(packageDir, "akamai-foo")}, packageState{Method: installMethodBinary}, installMethodBinary},
+		{[]string{filepath.Join(packageDir, "bin", "akamai-foo")}, packageState{}, installMethodBinary},
+		{[]string{"node", filepath.Join(packageDir, "bin", "akamai-foo.js")}, packageState{}, installMethodBinary},
+		{[]string{filepath.Join(packageDir, "akamai-foo")}, packageState{}, installMethodSource},
+		{[]string{filepath.Join(packageDir, "binaries", "akamai-foo")}, packageState{}, installMethodSource},
+	}
+
+	for _, tt := range methodTests {
+		if method := getInstallMethod(packageDir, tt.executable, tt.state); method != tt.method {
+			t.Errorf("getInstallMethod(%s, %+v) => %s, wanted: %s", strings.Join(tt.executable, " "), tt.state, method, tt.method)
+		}
+	}
+}
+
+func TestCheckOutdated(t *testing.T) {
+	home, err := ioutil.TempDir("", "akamai-cli-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	os.Setenv("AKAMAI_CLI_HOME", home)
+	defer os.Unsetenv("AKAMAI_CLI_HOME")
+
+	upstreamDir := filepath.Join(home, "upstream", "cli-foo")
+	upstream, err := git.PlainInit(upstreamDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := commitTestFiles(t, upstream, map[string]string{"cli.json": `{"commands": [{"name": "foo", "version": "1.0.0"}]}`})
+
+	packageDir := filepath.Join(home, ".akamai-cli", "src", "cli-foo")
+	repo, err := git.PlainClone(packageDir, false, &git.CloneOptions{URL: upstreamDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current := "1.0.0 (" + first.String()[:7] + ")"
+	if row, outdated := checkOutdated(packageDir, repo); outdated || row[1] != current || row[2] != current || row[3] != "up-to-date" {
+		t.Errorf("checkOutdated() => (%s, %t), wanted: %s up-to-date", strings.Join(row, " | "), outdated, current)
+	}
+
+	second := commitTestFiles(t, upstream, map[string]string{"cli.json": `{"commands": [{"name": "foo", "version": "1.1.0"}]}`})
+	latest := "1.1.0 (" + second.String()[:7] + ")"
+	if row, outdated := checkOutdated(packageDir, repo); !outdated || row[1] != current || row[2] != latest || row[3] != "outdated" {
+		t.Errorf("checkOutdated() => (%s, %t), wanted: %s outdated to %s", strings.Join(row, " | "), outdated, current, latest)
+	}
+
+	if head, err := repo.Head(); err != nil || head.Hash() != first || getPackageVersion(packageDir) != "1.0.0" {
+		t.Errorf("checkOutdated() => package was modified, wanted: %s", current)
+	}
+
+	if err := upstream.Storer.SetReference(plumbing.NewHashReference("refs/tags/v1.0.0", first)); err != nil {
+		t.Fatal(err)
+	}
+	setPackageState(packageDir, packageState{Strategy: updateStrategyTag})
+	if row, outdated := checkOutdated(packageDir, repo); outdated || row[2] != current {
+		t.Errorf("checkOutdated() => (%s, %t), wanted: %s up-to-date with latest tag", strings.Join(row, " | "), outdated, current)
+	}
+
+	os.RemoveAll(upstreamDir)
+	if row, outdated := checkOutdated(packageDir, repo); outdated || row[3] != "Unable to fetch updates" {
+		t.Errorf("checkOutdated() => (%s, %t), wanted: Unable to fetch updates", strings.Join(row, " | "), outdated)
+	}
+}
+
+func TestLinkPackage(t *testing.T) {
+	home, err := ioutil.TempDir("", "akamai-cli-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	os.Setenv("AKAMAI_CLI_HOME", home)
+	defer os.Unsetenv("AKAMAI_CLI_HOME")
+
+	newPackage := func(dir string) string {
+		os.MkdirAll(filepath.Join(dir, "bin"), 0755)
+		ioutil.WriteFile(filepath.Join(dir, "cli.json"), []byte(`{"commands": [{"name": "foo", "version": "1.0.0"}]}`), 0644)
+		ioutil.WriteFile(filepath.Join(dir, "bin", "akamai-foo"), []byte("#!/bin/sh\necho foo\n"), 0755)
+		return dir
+	}
+
+	packageDir := newPackage(filepath.Join(home, "work", "cli-foo"))
+	if err := linkPackage(packageDir, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if state := getPackageState(packageDir); state.Link != packageDir {
+		t.Errorf("linkPackage(%s) => link %s, wanted: %s", packageDir, state.Link, packageDir)
+	}
+
+	if dirs := getLinkedPackageDirs(); len(dirs) != 1 || dirs[0] != packageDir {
+		t.Errorf("getLinkedPackageDirs() => %s, wanted: %s", strings.Join(dirs, ", "), packageDir)
+	}
+
+	if dir := findPackageDir(filepath.Join(packageDir, "bin")); dir != packageDir {
+		t.Errorf("findPackageDir(%s) => %s, wanted: %s", filepath.Join(packageDir, "bin"), dir, packageDir)
+	}
+
+	if exec, err := findExec("foo"); err != nil || exec[len(exec)-1] != filepath.Join(packageDir, "bin", "akamai-foo") {
+		t.Errorf("findExec(foo) => (%s, %v), wanted: %s", strings.Join(exec, " "), err, filepath.Join(packageDir, "bin", "akamai-foo"))
+	}
+
+	otherDir := newPackage(filepath.Join(home, "other", "cli-foo"))
+	if err := linkPackage(otherDir, false); err == nil {
+		t.Errorf("linkPackage(%s) => linked, wanted: already linked to %s", otherDir, packageDir)
+	}
+
+	set := flag.NewFlagSet("uninstall", 0)
+	set.Parse([]string{"foo"})
+	if err := cmdUninstall(cli.NewContext(nil, set, nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	if state := getPackageState(packageDir); state.Link != "" {
+		t.Errorf("cmdUninstall(foo) => link %s, wanted: unlinked", state.Link)
+	}
+
+	if dirs := getLinkedPackageDirs(); len(dirs) != 0 {
+		t.Errorf("getLinkedPackageDirs() => %s, wanted: none", strings.Join(dirs, ", "))
+	}
+
+	if _, err := os.Stat(filepath.Join(packageDir, "cli.json")); err != nil {
+		t.Errorf("cmdUninstall(foo) => %s was removed, wanted: left in place", packageDir)
+	}
+}
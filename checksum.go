@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"runtime"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// getBinCommand fills in the values available to the bin and checksum-url templates
+func getBinCommand(cmd Command) Command {
+	cmd.Arch = runtime.GOARCH
+
+	cmd.OS = runtime.GOOS
+	if runtime.GOOS == "darwin" {
+		cmd.OS = "mac"
+	}
+
+	if runtime.GOOS == "windows" {
+		cmd.BinSuffix = ".exe"
+	}
+
+	return cmd
+}
+
+func renderBinTemplate(tpl string, cmd Command) (string, error) {
+	t, err := template.New("url").Parse(tpl)
+	if err != nil {
+		return "", err
+	}
+
+	buf := &bytes.Buffer{}
+	if err := t.Execute(buf, cmd); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// getBinChecksum returns the expected SHA256 checksum of the binary for the current platform,
+// or an empty string if the package does not declare one. Checksums are looked up in the
+// checksums map using the "<os>-<arch>" key first, then fetched from checksum-url.
+func getBinChecksum(cmd Command) (string, error) {
+	if checksum, ok := cmd.Checksums[cmd.OS+"-"+cmd.Arch]; ok {
+		return parseChecksum(checksum)
+	}
+
+	if cmd.ChecksumURL == "" {
+		return "", nil
+	}
+
+	url, err := renderBinTemplate(cmd.ChecksumURL, cmd)
+	if err != nil {
+		return "", err
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	res, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != 200 {
+		return "", fmt.Errorf("unable to fetch checksum from %s: %s", url, res.Status)
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return parseChecksum(string(body))
+}
+
+// parseChecksum accepts a plain hex encoded SHA256 checksum, or the output of sha256sum
+func parseChecksum(checksum string) (string, error) {
+	fields := strings.Fields(checksum)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("empty checksum")
+	}
+
+	checksum = strings.ToLower(strings.TrimPrefix(fields[0], "sha256:"))
+	if decoded, err := hex.DecodeString(checksum); err != nil || len(decoded) != 32 {
+		return "", fmt.Errorf("invalid SHA256 checksum: %s", fields[0])
+	}
+
+	return checksum, nil
+}
@@ -17,6 +17,9 @@ const (
 
 var config map[string]*ini.File = make(map[string]*ini.File)
 
+// layeredConfig is the global config with the project config layered over it, see openConfig
+var layeredConfig *ini.File
+
 func getConfigFilePath() (string, error) {
 	cliPath, err := getAkamaiCliPath()
 	if err != nil {
@@ -26,12 +29,52 @@ func getConfigFilePath() (string, error) {
 	return filepath.Join(cliPath, "config"), nil
 }
 
+// openConfig returns the config, with any values from the project config (see getProjectConfig) and the active
+// profile (see getProfileConfig) layered over the global config. Changes must be made using setConfigValue, so
+// that they are saved to the global config.
 func openConfig() (*ini.File, error) {
+	global, err := openGlobalConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	projectOverrides := getProjectConfig()
+	profileOverrides := getProfileConfig()
+	if len(projectOverrides) == 0 && len(profileOverrides) == 0 {
+		return global, nil
+	}
+
+	if layeredConfig == nil {
+		layeredConfig = ini.Empty()
+		for _, section := range global.Sections() {
+			for _, key := range section.Keys() {
+				layeredConfig.Section(section.Name()).Key(key.Name()).SetValue(key.String())
+			}
+		}
+
+		for _, overrides := range []map[string]map[string]string{projectOverrides, profileOverrides} {
+			for section, values := range overrides {
+				for key, value := range values {
+					layeredConfig.Section(section).Key(key).SetValue(value)
+				}
+			}
+		}
+	}
+
+	return layeredConfig, nil
+}
+
+// openGlobalConfig returns the global config, without project config values
+func openGlobalConfig() (*ini.File, error) {
 	path, err := getConfigFilePath()
 	if err != nil {
 		return nil, err
 	}
 
+	return openConfigFile(path)
+}
+
+func openConfigFile(path string) (*ini.File, error) {
 	if config[path] != nil {
 		return config[path], nil
 	}
@@ -51,24 +94,88 @@ func openConfig() (*ini.File, error) {
 	return config[path], nil
 }
 
-func saveConfig() error {
-	config, err := openConfig()
+// reloadConfigFile discards the cached copy of the file, so that changes made by other processes are picked up
+func reloadConfigFile(path string) (*ini.File, error) {
+	delete(config, path)
+	layeredConfig = nil
+
+	return openConfigFile(path)
+}
+
+// lockConfigFile prevents other processes from changing the file until the returned function is called
+func lockConfigFile(path string) (func(), error) {
+	lockPath := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".lock")
+	timeout := time.Now().Add(10 * time.Second)
+
+	for {
+		lock, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			lock.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		// Remove locks left behind by interrupted processes
+		if stat, err := os.Stat(lockPath); err == nil && stat.ModTime().Add(time.Minute).Before(time.Now()) {
+			os.Remove(lockPath)
+			continue
+		}
+
+		if time.Now().After(timeout) {
+			return nil, fmt.Errorf("timed out waiting for lock on %s", path)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// updateConfig reloads the config under a lock, makes the changes, and saves it, so that changes made by other
+// processes in the meantime are not overwritten
+func updateConfig(update func()) error {
+	path, err := getConfigFilePath()
+	if err != nil {
+		return err
+	}
+
+	unlock, err := lockConfigFile(path)
 	if err != nil {
 		return err
 	}
+	defer unlock()
 
+	if _, err := reloadConfigFile(path); err != nil {
+		return err
+	}
+
+	update()
+
+	return saveConfigFile(path)
+}
+
+func saveConfig() error {
 	path, err := getConfigFilePath()
 	if err != nil {
 		return err
 	}
 
+	return saveConfigFile(path)
+}
+
+func saveConfigFile(path string) error {
+	config, err := openConfigFile(path)
+	if err != nil {
+		return err
+	}
+
 	err = config.SaveTo(path)
 	if err != nil {
 		fmt.Println(err.Error())
 		return err
 	}
 
-
 	return nil
 }
 
@@ -140,15 +247,31 @@ func getConfigValue(sectionName string, key string) string {
 }
 
 func setConfigValue(sectionName string, key string, value string) {
-	config, err := openConfig()
+	config, err := openGlobalConfig()
 	if err != nil {
 		return
 	}
 
 	section := config.Section(sectionName)
 	section.Key(key).SetValue(value)
+	layeredConfig = nil
+}
+
+func unsetConfigValue(sectionName string, key string) {
+	config, err := openGlobalConfig()
+	if err != nil {
+		return
+	}
+
+	config.Section(sectionName).DeleteKey(key)
+	if len(config.Section(sectionName).Keys()) == 0 {
+		config.DeleteSection(sectionName)
+	}
+	layeredConfig = nil
 }
 
+// exportConfigEnv exports the [cli] config section to all commands. Other sections belong to the package with
+// the same name, and are only exported to that package's commands by exportPackageConfigEnv.
 func exportConfigEnv() {
 	migrateConfig()
 
@@ -157,12 +280,102 @@ func exportConfigEnv() {
 		return
 	}
 
-	for _, section := range config.Sections() {
-		envVar := "AKAMAI_" + strings.ToUpper(section.Name()) + "_"
+	for _, key := range config.Section("cli").Keys() {
+		os.Setenv(configEnvName("cli", key.Name()), key.String())
+	}
+}
 
-		for _, key := range section.Keys() {
-			envVar += strings.ToUpper(strings.Replace(key.Name(), "-", "_", -1))
-			os.Setenv(envVar, key.String())
+// exportPackageConfigEnv exports the package's config section, and the defaults for settings it declares in cli.json
+func exportPackageConfigEnv(packageName string, settings []packageSetting) {
+	for _, setting := range settings {
+		if value := setting.defaultValue(); value != "" {
+			os.Setenv(configEnvName(packageName, setting.Name), value)
 		}
 	}
-}
\ No newline at end of file
+
+	config, err := openConfig()
+	if err != nil {
+		return
+	}
+
+	section, err := config.GetSection(packageName)
+	if err != nil {
+		return
+	}
+
+	for _, key := range section.Keys() {
+		os.Setenv(configEnvName(packageName, key.Name()), key.String())
+	}
+}
+
+// validateConfigEnvName rejects a setting that would be exported as the same environment variable as another
+// setting, e.g. "a-b.c" and "a.b-c" are both exported as AKAMAI_A_B_C
+func validateConfigEnvName(section string, key string) error {
+	if strings.HasPrefix(section, profileSectionPrefix) {
+		parts := strings.SplitN(key, ".", 2)
+		if len(parts) != 2 {
+			return nil
+		}
+		section, key = parts[0], parts[1]
+	}
+
+	name := configEnvName(section, key)
+	for _, other := range getExportedConfigKeys(section) {
+		if other[0] == section && other[1] == key {
+			continue
+		}
+
+		if configEnvName(other[0], other[1]) == name {
+			return fmt.Errorf("\"%s.%s\" would be exported as %s, which is already used by \"%s.%s\"", section, key, name, other[0], other[1])
+		}
+	}
+
+	return nil
+}
+
+// getExportedConfigKeys returns the section and key of each setting in the config, including package settings
+// set in profiles, and of the settings declared in the cli.json of the package the given section belongs to
+func getExportedConfigKeys(section string) [][2]string {
+	var keys [][2]string
+	if dir := findPackageDirByName(section); dir != "" {
+		if cmdPackage, err := readPackage(dir); err == nil {
+			for _, setting := range cmdPackage.Settings {
+				keys = append(keys, [2]string{section, setting.Name})
+			}
+		}
+	}
+
+	config, err := openConfig()
+	if err != nil {
+		return keys
+	}
+
+	for _, configSection := range config.Sections() {
+		name := configSection.Name()
+		for _, key := range configSection.Keys() {
+			if !strings.HasPrefix(name, profileSectionPrefix) {
+				keys = append(keys, [2]string{name, key.Name()})
+			} else if parts := strings.SplitN(key.Name(), ".", 2); len(parts) == 2 {
+				keys = append(keys, [2]string{parts[0], parts[1]})
+			}
+		}
+	}
+
+	return keys
+}
+
+// configEnvName returns the environment variable a config value is exported as: AKAMAI_<SECTION>_<KEY>, where
+// the section and key are upper-cased, and any character other than A-Z and 0-9 is replaced with an underscore.
+// For example, last-upgrade-check in the [cli] section is exported as AKAMAI_CLI_LAST_UPGRADE_CHECK.
+func configEnvName(section string, key string) string {
+	escape := func(name string) string {
+		return strings.Map(func(r rune) rune {
+			if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+				return r
+			}
+			return '_'
+		}, strings.ToUpper(name))
+	}
+
+	return "AKAMAI_" + escape(section) + "_" + escape(key)
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli"
+)
+
+// cliConfigKeys lists the settings Akamai CLI uses in the [cli] section, and validates their values
+var cliConfigKeys = map[string]func(value string) error{
+	"config-version": func(value string) error {
+		if value != configVersion {
+			return fmt.Errorf("must be %s", configVersion)
+		}
+		return nil
+	},
+	"last-upgrade-check": func(value string) error {
+		if value == "never" || value == "ignore" {
+			return nil
+		}
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return fmt.Errorf("must be \"never\", \"ignore\", or an RFC3339 date")
+		}
+		return nil
+	},
+	"install-in-path":    validateConfigOneOf("yes", "no"),
+	"upgrade-notice":     validateConfigOneOf("true", "false"),
+	"require-signatures": validateConfigOneOf("true", "false"),
+	"latest-version": func(value string) error {
+		return nil
+	},
+	"upgrade-channel": validateUpgradeChannel,
+	"release-url": func(value string) error {
+		if u, err := url.Parse(value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("must be an http or https URL")
+		}
+		return nil
+	},
+	"package-index": func(value string) error {
+		return nil
+	},
+	"trusted-keys": func(value string) error {
+		for _, key := range strings.Split(value, ",") {
+			if _, err := parsePublicKey(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	"jobs":          validateConfigInt(1),
+	"keep-versions": validateConfigInt(0),
+}
+
+func validateConfigOneOf(values ...string) func(value string) error {
+	return func(value string) error {
+		for _, valid := range values {
+			if value == valid {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of: %s", strings.Join(values, ", "))
+	}
+}
+
+func validateConfigInt(min int) func(value string) error {
+	return func(value string) error {
+		if i, err := strconv.Atoi(value); err != nil || i < min {
+			return fmt.Errorf("must be a number, %d or greater", min)
+		}
+		return nil
+	}
+}
+
+// splitConfigKey splits "section.key" into its section and key. Profile sections are named "profile.<name>",
+// so "profile.<name>.key" is split after the profile name.
+func splitConfigKey(name string) (string, string, error) {
+	if strings.HasPrefix(name, profileSectionPrefix) {
+		parts := strings.SplitN(strings.TrimPrefix(name, profileSectionPrefix), ".", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return "", "", fmt.Errorf("invalid key \"%s\", must be in the format %s<name>.key", name, profileSectionPrefix)
+		}
+
+		return profileSectionPrefix + parts[0], parts[1], nil
+	}
+
+	parts := strings.SplitN(name, ".", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid key \"%s\", must be in the format section.key", name)
+	}
+
+	return parts[0], parts[1], nil
+}
+
+func validateConfigValue(section string, key string, value string) error {
+	if err := validateConfigEnvName(section, key); err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(section, profileSectionPrefix) {
+		return validateProfileValue(section, key, value)
+	}
+
+	if section != "cli" {
+		return validatePackageSetting(section, key, value)
+	}
+
+	validate, ok := cliConfigKeys[key]
+	if !ok {
+		return fmt.Errorf("unknown key \"cli.%s\"", key)
+	}
+
+	if err := validate(value); err != nil {
+		return fmt.Errorf("invalid value for \"cli.%s\": %s", key, err.Error())
+	}
+
+	return nil
+}
+
+func cmdConfig(c *cli.Context) error {
+	switch c.Args().First() {
+	case "get":
+		return cmdConfigGet(c)
+	case "set":
+		return cmdConfigSet(c)
+	case "unset":
+		return cmdConfigUnset(c)
+	case "list", "":
+		return cmdConfigList(c)
+	}
+
+	return cli.NewExitError(color.RedString("Unknown action \"%s\", must be one of: get, set, unset, list", c.Args().First()), 1)
+}
+
+func cmdConfigGet(c *cli.Context) error {
+	if len(c.Args()) != 2 {
+		return cli.NewExitError(color.RedString("Usage: %s config get <section.key>", self()), 1)
+	}
+
+	section, key, err := splitConfigKey(c.Args().Get(1))
+	if err != nil {
+		return cli.NewExitError(color.RedString(err.Error()), 1)
+	}
+
+	config, err := openConfig()
+	if err != nil {
+		return cli.NewExitError(color.RedString("Unable to read config: %s", err.Error()), 1)
+	}
+
+	if !config.Section(section).HasKey(key) {
+		return cli.NewExitError(color.RedString("Key \"%s\" is not set", c.Args().Get(1)), 1)
+	}
+
+	value := config.Section(section).Key(key).String()
+	if c.Bool("json") {
+		data, _ := json.Marshal(map[string]string{c.Args().Get(1): value})
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Println(value)
+
+	return nil
+}
+
+func cmdConfigSet(c *cli.Context) error {
+	if len(c.Args()) != 3 {
+		return cli.NewExitError(color.RedString("Usage: %s config set <section.key> <value>", self()), 1)
+	}
+
+	section, key, err := splitConfigKey(c.Args().Get(1))
+	if err != nil {
+		return cli.NewExitError(color.RedString(err.Error()), 1)
+	}
+
+	value := c.Args().Get(2)
+	if err := validateConfigValue(section, key, value); err != nil {
+		return cli.NewExitError(color.RedString(err.Error()), 1)
+	}
+
+	err = updateConfig(func() {
+		setConfigValue(section, key, value)
+	})
+	if err != nil {
+		return cli.NewExitError(color.RedString("Unable to save config: %s", err.Error()), 1)
+	}
+
+	return nil
+}
+
+func cmdConfigUnset(c *cli.Context) error {
+	if len(c.Args()) != 2 {
+		return cli.NewExitError(color.RedString("Usage: %s config unset <section.key>", self()), 1)
+	}
+
+	section, key, err := splitConfigKey(c.Args().Get(1))
+	if err != nil {
+		return cli.NewExitError(color.RedString(err.Error()), 1)
+	}
+
+	if section == "cli" && key == "config-version" {
+		return cli.NewExitError(color.RedString("Key \"cli.config-version\" cannot be unset"), 1)
+	}
+
+	err = updateConfig(func() {
+		unsetConfigValue(section, key)
+	})
+	if err != nil {
+		return cli.NewExitError(color.RedString("Unable to save config: %s", err.Error()), 1)
+	}
+
+	return nil
+}
+
+func cmdConfigList(c *cli.Context) error {
+	config, err := openConfig()
+	if err != nil {
+		return cli.NewExitError(color.RedString("Unable to read config: %s", err.Error()), 1)
+	}
+
+	filter := c.Args().Get(1)
+
+	values := make(map[string]map[string]string)
+	for _, section := range config.Sections() {
+		if len(section.Keys()) == 0 || (filter != "" && section.Name() != filter) {
+			continue
+		}
+
+		values[section.Name()] = make(map[string]string)
+		for _, key := range section.Keys() {
+			values[section.Name()][key.Name()] = key.String()
+		}
+	}
+
+	if c.Bool("json") {
+		data, _ := json.MarshalIndent(values, "", "  ")
+		fmt.Println(string(data))
+		return nil
+	}
+
+	for _, section := range config.Sections() {
+		if _, ok := values[section.Name()]; !ok {
+			continue
+		}
+
+		for _, key := range section.Keys() {
+			fmt.Printf("%s.%s = %s\n", section.Name(), key.Name(), key.String())
+		}
+	}
+
+	return nil
+}
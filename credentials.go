@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-ini/ini"
+	"github.com/mitchellh/go-homedir"
+)
+
+const (
+	defaultEdgercPath = "~/.edgerc"
+)
+
+// credentialKeys lists the credential settings that can be set by a profile or global flag, and the
+// environment variables they are exported to commands as
+var credentialKeys = map[string]string{
+	"edgerc":      "AKAMAI_EDGERC",
+	"section":     "AKAMAI_SECTION",
+	"account-key": "AKAMAI_ACCOUNT_KEY",
+}
+
+// credentialFlags lists the global flags for credential settings, in the order they are passed to commands
+// that declare support for them in their cli.json
+var credentialFlags = []struct {
+	name string
+	key  string
+}{
+	{"edgerc", "edgerc"},
+	{"section", "section"},
+	{"accountkey", "account-key"},
+}
+
+// globalCredentials holds the credential settings passed as global flags, which take precedence over the profile
+var globalCredentials = make(map[string]string)
+
+// getCredentials returns the credential settings of the active profile, overridden by any global flags
+func getCredentials() map[string]string {
+	credentials := getProfileCredentials()
+	for key, value := range globalCredentials {
+		credentials[key] = value
+	}
+
+	if path, ok := credentials["edgerc"]; ok {
+		if expanded, err := homedir.Expand(path); err == nil {
+			credentials["edgerc"] = expanded
+		}
+	}
+
+	return credentials
+}
+
+// setCredentials sets the credential settings passed as global flags. If verify is set, it first checks that the
+// .edgerc file, and the section if given, exist.
+func setCredentials(edgerc string, section string, accountKey string, verify bool) error {
+	for key, value := range map[string]string{"edgerc": edgerc, "section": section, "account-key": accountKey} {
+		if value != "" {
+			globalCredentials[key] = value
+		}
+	}
+
+	if !verify || (edgerc == "" && section == "") {
+		return nil
+	}
+
+	credentials := getCredentials()
+	path, err := getEdgercPath(credentials["edgerc"])
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("unable to find .edgerc file \"%s\"", path)
+	}
+
+	edgercFile, err := ini.Load(path)
+	if err != nil {
+		return fmt.Errorf("unable to read .edgerc file \"%s\": %s", path, err.Error())
+	}
+
+	if credentials["section"] != "" {
+		if _, err := edgercFile.GetSection(credentials["section"]); err != nil {
+			return fmt.Errorf("section \"%s\" not found in .edgerc file \"%s\"", credentials["section"], path)
+		}
+	}
+
+	return nil
+}
+
+// getEdgercPath returns the path of the .edgerc file to use: the given path, $AKAMAI_EDGERC, or ~/.edgerc
+func getEdgercPath(path string) (string, error) {
+	if path == "" {
+		path = os.Getenv("AKAMAI_EDGERC")
+	}
+
+	if path == "" {
+		path = defaultEdgercPath
+	}
+
+	return homedir.Expand(path)
+}
+
+// exportCredentialsEnv exports the credential settings of the active profile and global flags
+func exportCredentialsEnv() {
+	for key, value := range getCredentials() {
+		os.Setenv(credentialKeys[key], value)
+	}
+}
+
+// getCredentialArgs returns the flags to pass the credential settings to a command that declares support for them
+func getCredentialArgs(command Command) []string {
+	credentials := getCredentials()
+
+	var args []string
+	for _, flag := range credentialFlags {
+		if credentials[flag.key] == "" {
+			continue
+		}
+
+		for _, supported := range command.CredentialFlags {
+			if supported == flag.name {
+				args = append(args, "--"+flag.name, credentials[flag.key])
+			}
+		}
+	}
+
+	return args
+}
@@ -0,0 +1,6 @@
+package main
+
+import "os/exec"
+
+func detachProcess(cmd *exec.Cmd) {
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ini/ini"
+)
+
+const (
+	edgegridAlgorithm       = "EG1-HMAC-SHA256"
+	edgegridTimestampFormat = "20060102T15:04:05-0700"
+	defaultEdgegridMaxBody  = 131072
+)
+
+// edgegridCredentials are the credentials from an .edgerc section used to sign requests
+type edgegridCredentials struct {
+	ClientSecret string
+	Host         string
+	AccessToken  string
+	ClientToken  string
+	MaxBody      int
+}
+
+// loadEdgegridCredentials reads the credentials in the given section of an .edgerc file
+func loadEdgegridCredentials(path string, name string) (edgegridCredentials, error) {
+	edgerc, err := ini.Load(path)
+	if err != nil {
+		return edgegridCredentials{}, fmt.Errorf("unable to read .edgerc file \"%s\": %s", path, err.Error())
+	}
+
+	section, err := edgerc.GetSection(name)
+	if err != nil {
+		return edgegridCredentials{}, fmt.Errorf("section \"%s\" not found in .edgerc file \"%s\"", name, path)
+	}
+
+	if problems := verifyEdgercSection(section); len(problems) > 0 {
+		return edgegridCredentials{}, fmt.Errorf("invalid credentials in section \"%s\": %s", name, strings.Join(problems, ", "))
+	}
+
+	credentials := edgegridCredentials{
+		ClientSecret: getEdgercValue(section, "client_secret"),
+		Host:         getEdgercValue(section, "host"),
+		AccessToken:  getEdgercValue(section, "access_token"),
+		ClientToken:  getEdgercValue(section, "client_token"),
+		MaxBody:      defaultEdgegridMaxBody,
+	}
+
+	if value := getEdgercValue(section, "max-body"); value != "" {
+		credentials.MaxBody, _ = strconv.Atoi(value)
+	}
+
+	return credentials, nil
+}
+
+// signEdgegridRequest adds an EdgeGrid v1 Authorization header to the request
+func signEdgegridRequest(req *http.Request, credentials edgegridCredentials) error {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	nonce[6] = (nonce[6] & 0x0f) | 0x40
+	nonce[8] = (nonce[8] & 0x3f) | 0x80
+
+	return signEdgegridRequestAt(req, credentials, time.Now(), fmt.Sprintf("%x-%x-%x-%x-%x", nonce[0:4], nonce[4:6], nonce[6:8], nonce[8:10], nonce[10:]))
+}
+
+// signEdgegridRequestAt adds an EdgeGrid v1 Authorization header to the request, using the given timestamp and nonce
+func signEdgegridRequestAt(req *http.Request, credentials edgegridCredentials, timestamp time.Time, nonce string) error {
+	contentHash, err := getEdgegridContentHash(req, credentials.MaxBody)
+	if err != nil {
+		return err
+	}
+
+	authHeader := fmt.Sprintf(
+		"%s client_token=%s;access_token=%s;timestamp=%s;nonce=%s;",
+		edgegridAlgorithm,
+		credentials.ClientToken,
+		credentials.AccessToken,
+		timestamp.UTC().Format(edgegridTimestampFormat),
+		nonce,
+	)
+
+	data := strings.Join([]string{
+		strings.ToUpper(req.Method),
+		req.URL.Scheme,
+		req.URL.Host,
+		req.URL.RequestURI(),
+		"",
+		contentHash,
+		authHeader,
+	}, "\t")
+
+	signingKey := edgegridHmac(credentials.ClientSecret, timestamp.UTC().Format(edgegridTimestampFormat))
+	req.Header.Set("Authorization", authHeader+"signature="+edgegridHmac(signingKey, data))
+
+	return nil
+}
+
+// getEdgegridContentHash returns the hash of the first maxBody bytes of a POST request body, leaving the body unread
+func getEdgegridContentHash(req *http.Request, maxBody int) (string, error) {
+	if req.Method != http.MethodPost || req.Body == nil {
+		return "", nil
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return "", err
+	}
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if len(body) == 0 {
+		return "", nil
+	}
+
+	if maxBody > 0 && len(body) > maxBody {
+		body = body[:maxBody]
+	}
+
+	hash := sha256.Sum256(body)
+	return base64.StdEncoding.EncodeToString(hash[:]), nil
+}
+
+func edgegridHmac(key string, data string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(data))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
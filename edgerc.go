@@ -0,0 +1,390 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/go-ini/ini"
+	"github.com/urfave/cli"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// edgercKeys lists the keys each .edgerc section must set, in the order they are written and prompted for
+var edgercKeys = []string{"client_secret", "host", "access_token", "client_token"}
+
+// edgercSecrets lists the keys that are redacted by "akamai edgerc show"
+var edgercSecrets = map[string]bool{
+	"client_secret": true,
+	"access_token":  true,
+	"client_token":  true,
+}
+
+var edgercHostPattern = regexp.MustCompile(`^akab-[a-z0-9-]+\.[a-z]+\.akamaiapis\.net$`)
+
+func cmdEdgerc(c *cli.Context) error {
+	switch c.Args().First() {
+	case "list", "":
+		return cmdEdgercList(c)
+	case "add":
+		return cmdEdgercAdd(c)
+	case "remove":
+		return cmdEdgercRemove(c)
+	case "show":
+		return cmdEdgercShow(c)
+	case "verify":
+		return cmdEdgercVerify(c)
+	}
+
+	return cli.NewExitError(color.RedString("Unknown action \"%s\", must be one of: list, add, remove, show, verify", c.Args().First()), 1)
+}
+
+// openEdgerc returns the .edgerc file selected by --edgerc, the active profile, $AKAMAI_EDGERC, or ~/.edgerc
+func openEdgerc(create bool) (*ini.File, string, error) {
+	path, err := getEdgercPath(getCredentials()["edgerc"])
+	if err != nil {
+		return nil, "", err
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if create {
+			return ini.Empty(), path, nil
+		}
+		return nil, path, fmt.Errorf("unable to find .edgerc file \"%s\"", path)
+	}
+
+	edgerc, err := ini.Load(path)
+	if err != nil {
+		return nil, path, fmt.Errorf("unable to read .edgerc file \"%s\": %s", path, err.Error())
+	}
+
+	return edgerc, path, nil
+}
+
+func saveEdgerc(edgerc *ini.File, path string) error {
+	_, statErr := os.Stat(path)
+
+	if err := edgerc.SaveTo(path); err != nil {
+		return err
+	}
+
+	// The file holds secrets, so only the owner should be able to read a new one
+	if os.IsNotExist(statErr) {
+		return os.Chmod(path, 0600)
+	}
+
+	return nil
+}
+
+// getEdgercSections returns the sections in the file, without the unnamed section holding keys outside of a section
+func getEdgercSections(edgerc *ini.File) []*ini.Section {
+	var sections []*ini.Section
+	for _, section := range edgerc.Sections() {
+		if section.Name() == ini.DEFAULT_SECTION {
+			continue
+		}
+		sections = append(sections, section)
+	}
+
+	return sections
+}
+
+// getEdgercSectionName returns the section given as an argument, or by --section or the active profile
+func getEdgercSectionName(c *cli.Context, fallback string) string {
+	if section := c.Args().Get(1); section != "" {
+		return section
+	}
+
+	if section := getCredentials()["section"]; section != "" {
+		return section
+	}
+
+	return fallback
+}
+
+func cmdEdgercList(c *cli.Context) error {
+	edgerc, path, err := openEdgerc(false)
+	if err != nil {
+		return cli.NewExitError(color.RedString(err.Error()), 1)
+	}
+
+	color.Yellow("\nSections in %s:\n\n", path)
+	bold := color.New(color.FgWhite, color.Bold)
+	for _, section := range getEdgercSections(edgerc) {
+		bold.Printf("  %s", section.Name())
+		if host := getEdgercValue(section, "host"); host != "" {
+			fmt.Printf(" (%s)", host)
+		}
+		fmt.Println()
+	}
+	fmt.Println()
+
+	return nil
+}
+
+func cmdEdgercAdd(c *cli.Context) error {
+	name := getEdgercSectionName(c, "default")
+
+	edgerc, path, err := openEdgerc(true)
+	if err != nil {
+		return cli.NewExitError(color.RedString(err.Error()), 1)
+	}
+
+	if _, err := edgerc.GetSection(name); err == nil && !c.Bool("force") {
+		return cli.NewExitError(color.RedString("Section \"%s\" already exists in %s, use --force to replace it", name, path), 1)
+	}
+
+	var credentials map[string]string
+	if c.String("file") != "" {
+		data, err := ioutil.ReadFile(c.String("file"))
+		if err != nil {
+			return cli.NewExitError(color.RedString("Unable to read credentials: %s", err.Error()), 1)
+		}
+
+		credentials, err = parseCredentialExport(string(data))
+		if err != nil {
+			return cli.NewExitError(color.RedString("Unable to import credentials: %s", err.Error()), 1)
+		}
+	} else {
+		credentials = make(map[string]string)
+		for _, key := range edgercKeys {
+			fmt.Printf("%s: ", key)
+			credentials[key] = normalizeEdgercHost(key, strings.TrimSpace(readEdgercValue(key)))
+		}
+	}
+
+	edgerc.DeleteSection(name)
+	section, err := edgerc.NewSection(name)
+	if err != nil {
+		return cli.NewExitError(color.RedString("Unable to add section: %s", err.Error()), 1)
+	}
+
+	for _, key := range append(edgercKeys, "max-body") {
+		if value, ok := credentials[key]; ok {
+			section.Key(key).SetValue(value)
+		}
+	}
+
+	if problems := verifyEdgercSection(section); len(problems) > 0 {
+		return cli.NewExitError(color.RedString("Invalid credentials for section \"%s\":\n  %s", name, strings.Join(problems, "\n  ")), 1)
+	}
+
+	if err := saveEdgerc(edgerc, path); err != nil {
+		return cli.NewExitError(color.RedString("Unable to save .edgerc file: %s", err.Error()), 1)
+	}
+
+	fmt.Printf("Added section \"%s\" to %s\n", name, color.BlueString(path))
+
+	return nil
+}
+
+// readEdgercValue reads the value of a key from stdin, without echoing secrets if stdin is a terminal
+func readEdgercValue(key string) string {
+	if edgercSecrets[key] && terminal.IsTerminal(int(os.Stdin.Fd())) {
+		value, _ := terminal.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Println()
+		return string(value)
+	}
+
+	value := ""
+	fmt.Scanln(&value)
+	return value
+}
+
+func cmdEdgercRemove(c *cli.Context) error {
+	if len(c.Args()) != 2 {
+		return cli.NewExitError(color.RedString("Usage: %s edgerc remove <section>", self()), 1)
+	}
+	name := c.Args().Get(1)
+
+	edgerc, path, err := openEdgerc(false)
+	if err != nil {
+		return cli.NewExitError(color.RedString(err.Error()), 1)
+	}
+
+	if _, err := edgerc.GetSection(name); err != nil {
+		return cli.NewExitError(color.RedString("Section \"%s\" not found in %s", name, path), 1)
+	}
+
+	edgerc.DeleteSection(name)
+	if err := saveEdgerc(edgerc, path); err != nil {
+		return cli.NewExitError(color.RedString("Unable to save .edgerc file: %s", err.Error()), 1)
+	}
+
+	fmt.Printf("Removed section \"%s\" from %s\n", name, color.BlueString(path))
+
+	return nil
+}
+
+func cmdEdgercShow(c *cli.Context) error {
+	name := getEdgercSectionName(c, "default")
+
+	edgerc, path, err := openEdgerc(false)
+	if err != nil {
+		return cli.NewExitError(color.RedString(err.Error()), 1)
+	}
+
+	section, err := edgerc.GetSection(name)
+	if err != nil {
+		return cli.NewExitError(color.RedString("Section \"%s\" not found in %s", name, path), 1)
+	}
+
+	color.Yellow("\n[%s]\n", name)
+	for _, key := range section.Keys() {
+		value := key.String()
+		if edgercSecrets[key.Name()] {
+			value = redactSecret(value)
+		}
+		printInfo(key.Name(), value)
+	}
+	fmt.Println()
+
+	return nil
+}
+
+func cmdEdgercVerify(c *cli.Context) error {
+	edgerc, path, err := openEdgerc(false)
+	if err != nil {
+		return cli.NewExitError(color.RedString(err.Error()), 1)
+	}
+
+	sections := getEdgercSections(edgerc)
+	if name := getEdgercSectionName(c, ""); name != "" {
+		section, err := edgerc.GetSection(name)
+		if err != nil {
+			return cli.NewExitError(color.RedString("Section \"%s\" not found in %s", name, path), 1)
+		}
+		sections = []*ini.Section{section}
+	}
+
+	failed := false
+	if len(edgerc.Section(ini.DEFAULT_SECTION).Keys()) > 0 {
+		fmt.Println("Verifying keys outside of a section... [" + color.RedString("FAIL") + "]")
+		color.Red("  keys must be in a [section]")
+		failed = true
+	}
+
+	if len(sections) == 0 {
+		return cli.NewExitError(color.RedString("No sections found in %s", path), 1)
+	}
+
+	for _, section := range sections {
+		problems := verifyEdgercSection(section)
+		if len(problems) == 0 {
+			fmt.Printf("Verifying section \"%s\"... [%s]\n", section.Name(), color.GreenString("OK"))
+			continue
+		}
+
+		failed = true
+		fmt.Printf("Verifying section \"%s\"... [%s]\n", section.Name(), color.RedString("FAIL"))
+		for _, problem := range problems {
+			color.Red("  %s", problem)
+		}
+	}
+
+	if failed {
+		return cli.NewExitError("", 1)
+	}
+
+	return nil
+}
+
+// verifyEdgercSection returns the problems with the credentials in a section, if any
+func verifyEdgercSection(section *ini.Section) []string {
+	var problems []string
+	for _, key := range edgercKeys {
+		if getEdgercValue(section, key) == "" {
+			problems = append(problems, fmt.Sprintf("%s is missing", key))
+		}
+	}
+
+	if host := getEdgercValue(section, "host"); host != "" && !edgercHostPattern.MatchString(host) {
+		problems = append(problems, fmt.Sprintf("host \"%s\" must be in the format akab-<id>.luna.akamaiapis.net, without a scheme or path", host))
+	}
+
+	for _, key := range []string{"access_token", "client_token"} {
+		if value := getEdgercValue(section, key); value != "" && !strings.HasPrefix(value, "akab-") {
+			problems = append(problems, fmt.Sprintf("%s must start with akab-", key))
+		}
+	}
+
+	if value := getEdgercValue(section, "max-body"); value != "" {
+		if size, err := strconv.Atoi(value); err != nil || size <= 0 {
+			problems = append(problems, "max-body must be a positive number")
+		}
+	}
+
+	return problems
+}
+
+// getEdgercValue returns the value of a key, without adding it to the section if it is not set
+func getEdgercValue(section *ini.Section, key string) string {
+	if !section.HasKey(key) {
+		return ""
+	}
+
+	return section.Key(key).String()
+}
+
+// parseCredentialExport parses the credentials file downloaded when creating an API client, which lists
+// client_secret, host, access_token and client_token, one per line, separated from their values by "=", ":"
+// or whitespace. A [section] header, if any, is ignored.
+func parseCredentialExport(data string) (map[string]string, error) {
+	credentials := make(map[string]string)
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "[") {
+			continue
+		}
+
+		i := strings.IndexAny(line, "=:\t ")
+		if i == -1 {
+			continue
+		}
+
+		key := strings.ToLower(line[:i])
+		value := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line[i:]), "=:"))
+		if key == "max-body" || key == "max_body" {
+			credentials["max-body"] = value
+			continue
+		}
+
+		credentials[key] = normalizeEdgercHost(key, value)
+	}
+
+	var missing []string
+	for _, key := range edgercKeys {
+		if credentials[key] == "" {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing %s", strings.Join(missing, ", "))
+	}
+
+	return credentials, nil
+}
+
+// normalizeEdgercHost removes the scheme and trailing slash some exports include in the host
+func normalizeEdgercHost(key string, value string) string {
+	if key != "host" {
+		return value
+	}
+
+	value = strings.TrimPrefix(value, "https://")
+	return strings.TrimSuffix(value, "/")
+}
+
+// redactSecret hides all but the last four characters of a secret
+func redactSecret(value string) string {
+	if len(value) <= 8 {
+		return strings.Repeat("*", len(value))
+	}
+
+	return strings.Repeat("*", 8) + value[len(value)-4:]
+}
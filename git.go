@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/src-d/go-git.v4"
+	gitconfig "gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/storer"
+)
+
+const (
+	updateStrategyHead = "head"
+	updateStrategyTag  = "tag"
+)
+
+func fetchRemote(repo *git.Repository) error {
+	err := repo.Fetch(&git.FetchOptions{
+		RemoteName: git.DefaultRemoteName,
+		RefSpecs: []gitconfig.RefSpec{
+			gitconfig.RefSpec("+refs/heads/*:refs/remotes/" + git.DefaultRemoteName + "/*"),
+			gitconfig.RefSpec("+refs/tags/*:refs/tags/*"),
+		},
+	})
+
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return err
+	}
+
+	return nil
+}
+
+// resolveRef finds the commit for a tag, branch or (abbreviated) commit hash
+func resolveRef(repo *git.Repository, ref string) (plumbing.Hash, error) {
+	names := []plumbing.ReferenceName{
+		plumbing.ReferenceName("refs/tags/" + ref),
+		plumbing.ReferenceName("refs/remotes/" + git.DefaultRemoteName + "/" + ref),
+		plumbing.ReferenceName("refs/heads/" + ref),
+	}
+
+	for _, name := range names {
+		if reference, err := repo.Reference(name, true); err == nil {
+			return peelToCommit(repo, reference.Hash())
+		}
+	}
+
+	if len(ref) < 4 || len(ref) > 40 || strings.Trim(strings.ToLower(ref), "0123456789abcdef") != "" {
+		return plumbing.ZeroHash, fmt.Errorf("unable to find ref \"%s\"", ref)
+	}
+
+	if commit, err := repo.CommitObject(plumbing.NewHash(ref)); err == nil {
+		return commit.Hash, nil
+	}
+
+	commits, err := repo.CommitObjects()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	hash := plumbing.ZeroHash
+	err = commits.ForEach(func(commit *object.Commit) error {
+		if strings.HasPrefix(commit.Hash.String(), strings.ToLower(ref)) {
+			hash = commit.Hash
+			return storer.ErrStop
+		}
+		return nil
+	})
+
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	if hash.IsZero() {
+		return plumbing.ZeroHash, fmt.Errorf("unable to find ref \"%s\"", ref)
+	}
+
+	return hash, nil
+}
+
+func peelToCommit(repo *git.Repository, hash plumbing.Hash) (plumbing.Hash, error) {
+	if tag, err := repo.TagObject(hash); err == nil {
+		commit, err := tag.Commit()
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		return commit.Hash, nil
+	}
+
+	return hash, nil
+}
+
+func checkoutHash(repo *git.Repository, hash plumbing.Hash) error {
+	workdir, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	return workdir.Checkout(&git.CheckoutOptions{
+		Hash:  hash,
+		Force: true,
+	})
+}
+
+// resolveUpdateTarget finds the commit a package should be updated to: its
+// pinned ref, the latest semver tag, or the remote HEAD
+func resolveUpdateTarget(repo *git.Repository, state packageState) (plumbing.Hash, error) {
+	if state.Ref != "" {
+		return resolveRef(repo, state.Ref)
+	}
+
+	if state.Strategy == updateStrategyTag {
+		tag, err := latestSemverTag(repo)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		ref, err := repo.Reference(plumbing.ReferenceName("refs/tags/"+tag), true)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		return peelToCommit(repo, ref.Hash())
+	}
+
+	branch := getRemoteHeadBranch(repo)
+	ref, err := repo.Reference(plumbing.ReferenceName("refs/remotes/"+git.DefaultRemoteName+"/"+branch), true)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	return ref.Hash(), nil
+}
+
+// getRemoteHeadBranch returns the default branch of the remote, falling back to master
+func getRemoteHeadBranch(repo *git.Repository) string {
+	remote, err := repo.Remote(git.DefaultRemoteName)
+	if err != nil {
+		return "master"
+	}
+
+	refs, err := remote.List(&git.ListOptions{})
+	if err != nil {
+		return "master"
+	}
+
+	var head *plumbing.Reference
+	for _, ref := range refs {
+		if ref.Name() == plumbing.HEAD {
+			head = ref
+			break
+		}
+	}
+
+	if head == nil {
+		return "master"
+	}
+
+	if head.Type() == plumbing.SymbolicReference {
+		return strings.TrimPrefix(head.Target().String(), "refs/heads/")
+	}
+
+	for _, ref := range refs {
+		if ref.Name().IsBranch() && ref.Hash() == head.Hash() {
+			return strings.TrimPrefix(ref.Name().String(), "refs/heads/")
+		}
+	}
+
+	return "master"
+}
+
+var semverTag = regexp.MustCompile(`^v?(\d+(\.\d+){0,2})$`)
+
+func latestSemverTag(repo *git.Repository) (string, error) {
+	tags, err := repo.Tags()
+	if err != nil {
+		return "", err
+	}
+
+	latestTag := ""
+	latestVersion := ""
+	err = tags.ForEach(func(ref *plumbing.Reference) error {
+		name := strings.TrimPrefix(ref.Name().String(), "refs/tags/")
+		matches := semverTag.FindStringSubmatch(name)
+		if len(matches) == 0 {
+			return nil
+		}
+
+		if latestTag == "" || versionCompare(latestVersion, matches[1]) == 1 {
+			latestTag = name
+			latestVersion = matches[1]
+		}
+		return nil
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	if latestTag == "" {
+		return "", fmt.Errorf("no version tags found")
+	}
+
+	return latestTag, nil
+}
+
+// describeCommit returns a human readable name for a commit, e.g. "v1.2.0 (3f9d52f)"
+func describeCommit(repo *git.Repository, hash plumbing.Hash) string {
+	short := hash.String()[:7]
+
+	refs, err := repo.References()
+	if err != nil {
+		return short
+	}
+
+	tagName := ""
+	branchName := ""
+	refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference {
+			return nil
+		}
+
+		switch {
+		case ref.Name().IsTag() && tagName == "":
+			if commit, err := peelToCommit(repo, ref.Hash()); err == nil && commit == hash {
+				tagName = strings.TrimPrefix(ref.Name().String(), "refs/tags/")
+			}
+		case ref.Name().IsRemote() && branchName == "" && ref.Hash() == hash:
+			branchName = strings.TrimPrefix(ref.Name().String(), "refs/remotes/"+git.DefaultRemoteName+"/")
+		}
+		return nil
+	})
+
+	if tagName != "" {
+		return tagName + " (" + short + ")"
+	}
+
+	if branchName != "" {
+		return branchName + " (" + short + ")"
+	}
+
+	return short
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli"
+)
+
+// httpRequest is a request made by "akamai http", relative to the host in the .edgerc section
+type httpRequest struct {
+	Method  string
+	Path    string
+	Query   url.Values
+	Headers http.Header
+	Body    []byte
+}
+
+func cmdHTTP(c *cli.Context) error {
+	if len(c.Args()) != 2 {
+		return cli.NewExitError(color.RedString("Usage: %s http <METHOD> <path>", self()), 1)
+	}
+
+	request, err := getHTTPRequest(c)
+	if err != nil {
+		return cli.NewExitError(color.RedString(err.Error()), 1)
+	}
+
+	settings := getCredentials()
+	path, err := getEdgercPath(settings["edgerc"])
+	if err != nil {
+		return cli.NewExitError(color.RedString(err.Error()), 1)
+	}
+
+	section := settings["section"]
+	if section == "" {
+		section = "default"
+	}
+
+	credentials, err := loadEdgegridCredentials(path, section)
+	if err != nil {
+		return cli.NewExitError(color.RedString(err.Error()), 1)
+	}
+
+	if accountKey := settings["account-key"]; accountKey != "" {
+		request.Query.Set("accountSwitchKey", accountKey)
+	}
+
+	client := &http.Client{Timeout: time.Duration(c.Int("timeout")) * time.Second}
+	resp, err := sendEdgegridRequest(client, credentials, request)
+	if err != nil {
+		return cli.NewExitError(color.RedString("Request failed: %s", err.Error()), 1)
+	}
+	defer resp.Body.Close()
+
+	if err := printHTTPResponse(os.Stdout, resp, c.Bool("include"), !c.Bool("raw")); err != nil {
+		return cli.NewExitError(color.RedString("Unable to read response: %s", err.Error()), 1)
+	}
+
+	if resp.StatusCode >= 400 {
+		return cli.NewExitError("", 1)
+	}
+
+	return nil
+}
+
+// getHTTPRequest builds the request from the arguments and --query, --header and --body flags
+func getHTTPRequest(c *cli.Context) (httpRequest, error) {
+	request := httpRequest{
+		Method:  strings.ToUpper(c.Args().Get(0)),
+		Path:    c.Args().Get(1),
+		Query:   url.Values{},
+		Headers: http.Header{},
+	}
+
+	for _, param := range c.StringSlice("query") {
+		parts := strings.SplitN(param, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return request, fmt.Errorf("invalid query parameter \"%s\", must be in the format name=value", param)
+		}
+		request.Query.Add(parts[0], parts[1])
+	}
+
+	for _, header := range c.StringSlice("header") {
+		parts := strings.SplitN(header, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return request, fmt.Errorf("invalid header \"%s\", must be in the format Name: value", header)
+		}
+		request.Headers.Add(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	}
+
+	body := c.String("body")
+	if body == "" {
+		return request, nil
+	}
+
+	var err error
+	switch {
+	case body == "@-":
+		request.Body, err = ioutil.ReadAll(os.Stdin)
+	case strings.HasPrefix(body, "@"):
+		request.Body, err = ioutil.ReadFile(strings.TrimPrefix(body, "@"))
+	default:
+		request.Body = []byte(body)
+	}
+	if err != nil {
+		return request, fmt.Errorf("unable to read body: %s", err.Error())
+	}
+
+	if request.Headers.Get("Content-Type") == "" {
+		if !json.Valid(request.Body) {
+			return request, fmt.Errorf("body is not valid JSON, set a Content-Type header to send other content")
+		}
+		request.Headers.Set("Content-Type", "application/json")
+	}
+
+	return request, nil
+}
+
+// sendEdgegridRequest sends the request to the host in the credentials, signed using EdgeGrid
+func sendEdgegridRequest(client *http.Client, credentials edgegridCredentials, request httpRequest) (*http.Response, error) {
+	u, err := url.Parse(request.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Scheme = "https"
+	u.Host = credentials.Host
+	if !strings.HasPrefix(u.Path, "/") {
+		u.Path = "/" + u.Path
+	}
+
+	query := u.Query()
+	for name, values := range request.Query {
+		for _, value := range values {
+			query.Add(name, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	var body io.Reader
+	if len(request.Body) > 0 {
+		body = bytes.NewReader(request.Body)
+	}
+
+	req, err := http.NewRequest(request.Method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range request.Headers {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	if err := signEdgegridRequest(req, credentials); err != nil {
+		return nil, err
+	}
+
+	return client.Do(req)
+}
+
+// printHTTPResponse writes the response body, preceded by the status and headers if include is set. When
+// pretty is set, JSON bodies are indented.
+func printHTTPResponse(w io.Writer, resp *http.Response, include bool, pretty bool) error {
+	if include {
+		status := color.GreenString(resp.Status)
+		if resp.StatusCode >= 400 {
+			status = color.RedString(resp.Status)
+		}
+		fmt.Fprintf(w, "%s %s\n", resp.Proto, status)
+
+		names := make([]string, 0, len(resp.Header))
+		for name := range resp.Header {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			for _, value := range resp.Header[name] {
+				fmt.Fprintf(w, "%s: %s\n", color.CyanString(name), value)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if pretty && strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, body, "", "  "); err == nil {
+			body = indented.Bytes()
+		}
+	}
+
+	if len(body) > 0 {
+		w.Write(body)
+		if body[len(body)-1] != '\n' {
+			fmt.Fprintln(w)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+func cmdInfo(c *cli.Context) error {
+	if !c.Args().Present() {
+		return cli.NewExitError(color.RedString("You must specify a command"), 1)
+	}
+
+	cmd := strings.ToLower(c.Args().First())
+	for _, cmdPackage := range getCommands() {
+		for _, command := range cmdPackage.Commands {
+			for _, alias := range command.Aliases {
+				if alias == cmd {
+					cmd = command.Name
+				}
+			}
+		}
+	}
+
+	executable, err := findExec(cmd)
+	if err != nil {
+		return cli.NewExitError(color.RedString("Command \"%s\" not found. Try \"%s help\".\n", cmd, self()), 1)
+	}
+
+	packageDir := findPackageDir(filepath.Dir(executable[len(executable)-1]))
+	if packageDir == "" {
+		return cli.NewExitError(color.RedString("Unable to find package for command \"%s\", was it installed using "+color.CyanString("\"akamai install\"")+"?", cmd), 1)
+	}
+
+	cmdPackage, err := readPackage(packageDir)
+	if err != nil {
+		return cli.NewExitError(color.RedString("Unable to read package: %s", err.Error()), 1)
+	}
+
+	state := getPackageState(packageDir)
+	bold := color.New(color.FgWhite, color.Bold)
+
+	color.Yellow("\nPackage:\n")
+	printInfo("Name", filepath.Base(packageDir))
+	printInfo("Path", packageDir)
+	language := determineCommandLanguage(cmdPackage)
+	if language == "" {
+		language = "unknown"
+	}
+	printInfo("Language", language)
+	printInfo("Installed from", getInstallMethod(packageDir, executable, state))
+
+	color.Yellow("\nCommands:\n")
+	for _, command := range cmdPackage.Commands {
+		bold.Printf("  %s", command.Name)
+		if command.Version != "" {
+			fmt.Printf(" [v%s]", strings.TrimPrefix(command.Version, "v"))
+		}
+		fmt.Println()
+		if len(command.Aliases) > 0 {
+			fmt.Printf("    Aliases: %s\n", strings.Join(command.Aliases, ", "))
+		}
+		if command.Description != "" {
+			fmt.Printf("    Description: %s\n", command.Description)
+		}
+		if command.Bin != "" {
+			fmt.Printf("    Binary URL: %s\n", command.Bin)
+		}
+	}
+
+	color.Yellow("\nRequirements:\n")
+	requirements := map[string]string{
+		"go":     cmdPackage.Requirements.Go,
+		"php":    cmdPackage.Requirements.Php,
+		"node":   cmdPackage.Requirements.Node,
+		"ruby":   cmdPackage.Requirements.Ruby,
+		"python": cmdPackage.Requirements.Python,
+	}
+	found := false
+	for _, name := range []string{"go", "php", "node", "ruby", "python"} {
+		if requirements[name] != "" {
+			printInfo(name, requirements[name])
+			found = true
+		}
+	}
+	if !found {
+		fmt.Println("  none")
+	}
+
+	if len(cmdPackage.Settings) > 0 {
+		color.Yellow("\nSettings:\n")
+		packageName := getPackageName(packageDir)
+		for _, setting := range cmdPackage.Settings {
+			bold.Printf("  %s.%s", packageName, setting.Name)
+			fmt.Printf(" (%s)\n", setting.getType())
+			if setting.Description != "" {
+				fmt.Printf("    Description: %s\n", setting.Description)
+			}
+			if value := setting.defaultValue(); value != "" {
+				fmt.Printf("    Default: %s\n", value)
+			}
+			if value := getConfigValue(packageName, setting.Name); value != "" {
+				fmt.Printf("    Value: %s\n", value)
+			}
+			fmt.Printf("    Environment: %s\n", configEnvName(packageName, setting.Name))
+		}
+	}
+
+	color.Yellow("\nRepository:\n")
+	if repo, err := git.PlainOpen(packageDir); err == nil {
+		if remote, err := repo.Remote(git.DefaultRemoteName); err == nil && len(remote.Config().URLs) > 0 {
+			printInfo("Remote", remote.Config().URLs[0])
+		}
+		if head, err := repo.Head(); err == nil {
+			printInfo("Commit", describeCommit(repo, head.Hash()))
+		}
+	} else {
+		fmt.Println("  not a git repository")
+	}
+	if state.Ref != "" {
+		printInfo("Pinned to", state.Ref)
+	}
+	if state.Strategy != "" {
+		printInfo("Update strategy", state.Strategy)
+	}
+
+	color.Yellow("\nExecutable:\n")
+	printInfo("Command", strings.Join(executable, " "))
+	fmt.Println()
+
+	return nil
+}
+
+func printInfo(label string, value string) {
+	fmt.Printf("  %s: %s\n", label, value)
+}
+
+func getInstallMethod(packageDir string, executable []string, state packageState) string {
+	if state.Link != "" {
+		return "link"
+	}
+
+	if state.Method != "" {
+		return state.Method
+	}
+
+	if strings.HasPrefix(executable[len(executable)-1], filepath.Join(packageDir, "bin")+string(filepath.Separator)) {
+		return installMethodBinary
+	}
+
+	return installMethodSource
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli"
+)
+
+// linkPackage registers a local package directory, which is used in-place
+func linkPackage(dir string, forceBinary bool) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return cli.NewExitError(color.RedString("Unable to determine package path: %s", err.Error()), 1)
+	}
+
+	fmt.Printf("Attempting to link command from %s...", dir)
+
+	if _, err := os.Stat(filepath.Join(dir, "cli.json")); err != nil {
+		fmt.Println("... [" + color.RedString("FAIL") + "]")
+		return cli.NewExitError(color.RedString("Package does not contain a cli.json file."), 1)
+	}
+
+	srcPath, err := getAkamaiCliSrcPath()
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(filepath.Join(srcPath, filepath.Base(dir))); err == nil {
+		fmt.Println("... [" + color.RedString("FAIL") + "]")
+		return cli.NewExitError(color.RedString("Package \"%s\" is already installed", filepath.Base(dir)), 1)
+	}
+
+	if link := getPackageState(dir).Link; link != "" && link != dir {
+		fmt.Println("... [" + color.RedString("FAIL") + "]")
+		return cli.NewExitError(color.RedString("Package \"%s\" is already linked to %s", filepath.Base(dir), link), 1)
+	}
+
+	fmt.Println("... [" + color.GreenString("OK") + "]")
+
+	if !installPackage(dir, getBinaryInstall(forceBinary)) {
+		return cli.NewExitError("", 1)
+	}
+
+	state := getPackageState(dir)
+	state.Link = dir
+	setPackageState(dir, state)
+
+	return nil
+}
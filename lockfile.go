@@ -0,0 +1,256 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+const (
+	lockfileVersion = 1
+)
+
+type lockfile struct {
+	Version  int             `json:"version"`
+	Packages []lockedPackage `json:"packages"`
+}
+
+type lockedPackage struct {
+	Name     string `json:"name"`
+	Repo     string `json:"repo"`
+	Ref      string `json:"ref,omitempty"`
+	Commit   string `json:"commit"`
+	Version  string `json:"version"`
+	Method   string `json:"method"`
+	Strategy string `json:"strategy,omitempty"`
+}
+
+func getLockfilePath() (string, error) {
+	cliPath, err := getAkamaiCliPath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(cliPath, "packages.lock"), nil
+}
+
+func buildLockfile() lockfile {
+	lock := lockfile{Version: lockfileVersion, Packages: []lockedPackage{}}
+
+	srcPath, err := getAkamaiCliSrcPath()
+	if err != nil {
+		return lock
+	}
+
+	dirs, _ := filepath.Glob(filepath.Join(srcPath, "*"))
+	for _, dir := range dirs {
+		repo, err := git.PlainOpen(dir)
+		if err != nil {
+			continue
+		}
+
+		head, err := repo.Head()
+		if err != nil {
+			continue
+		}
+
+		state := getPackageState(dir)
+		locked := lockedPackage{
+			Name:     filepath.Base(dir),
+			Repo:     state.Repo,
+			Ref:      state.Ref,
+			Commit:   head.Hash().String(),
+			Method:   state.Method,
+			Strategy: state.Strategy,
+		}
+
+		if remote, err := repo.Remote(git.DefaultRemoteName); err == nil && len(remote.Config().URLs) > 0 {
+			locked.Repo = remote.Config().URLs[0]
+		}
+
+		if locked.Method == "" {
+			locked.Method = installMethodSource
+		}
+
+		if cmdPackage, err := readPackage(dir); err == nil && len(cmdPackage.Commands) > 0 {
+			locked.Version = cmdPackage.Commands[0].Version
+		}
+
+		lock.Packages = append(lock.Packages, locked)
+	}
+
+	return lock
+}
+
+func readLockfile(path string) (lockfile, error) {
+	var lock lockfile
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return lock, err
+	}
+
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return lock, err
+	}
+
+	if lock.Version != lockfileVersion {
+		return lock, fmt.Errorf("unsupported lockfile version: %d", lock.Version)
+	}
+
+	return lock, nil
+}
+
+func writeLockfile(path string, lock lockfile) error {
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so the lockfile is never left half-written
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+func updateLockfile() error {
+	// The lockfile is updated once all parallel installs or updates have finished
+	if isParallelWorker() {
+		return nil
+	}
+
+	path, err := getLockfilePath()
+	if err != nil {
+		return err
+	}
+
+	return writeLockfile(path, buildLockfile())
+}
+
+func cmdLock(c *cli.Context) error {
+	lock := buildLockfile()
+
+	if !c.Args().Present() {
+		data, err := json.MarshalIndent(lock, "", "  ")
+		if err != nil {
+			return cli.NewExitError(color.RedString("Unable to create lockfile: %s", err.Error()), 1)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	path := c.Args().First()
+	if err := writeLockfile(path, lock); err != nil {
+		return cli.NewExitError(color.RedString("Unable to write lockfile: %s", err.Error()), 1)
+	}
+
+	fmt.Printf("Locked %d package(s) to %s\n", len(lock.Packages), color.BlueString(path))
+
+	return nil
+}
+
+func installFromLockfile(path string, forceBinary bool) error {
+	if path == "" {
+		var err error
+		path, err = getLockfilePath()
+		if err != nil {
+			return err
+		}
+	}
+
+	lock, err := readLockfile(path)
+	if err != nil {
+		return cli.NewExitError(color.RedString("Unable to read lockfile: %s", err.Error()), 1)
+	}
+
+	srcPath, err := getAkamaiCliSrcPath()
+	if err != nil {
+		return err
+	}
+
+	oldCmds := getCommands()
+
+	locked := make(map[string]bool)
+	for _, pkg := range lock.Packages {
+		locked[pkg.Name] = true
+		packageDir := filepath.Join(srcPath, pkg.Name)
+		binary := getBinaryInstall(forceBinary)
+		if pkg.Method == installMethodBinary {
+			binary = binaryOnly
+		}
+
+		if _, err := os.Stat(packageDir); err == nil {
+			if err := syncPackage(packageDir, pkg, binary); err != nil {
+				return err
+			}
+		} else {
+			packageDir, err = installRepo(pkg.Repo, pkg.Commit, binary)
+			if err != nil {
+				return err
+			}
+		}
+
+		state := getPackageState(packageDir)
+		state.Repo = pkg.Repo
+		state.Ref = pkg.Ref
+		state.Strategy = pkg.Strategy
+		setPackageState(packageDir, state)
+	}
+
+	dirs, _ := filepath.Glob(filepath.Join(srcPath, "*"))
+	for _, dir := range dirs {
+		if !locked[filepath.Base(dir)] {
+			color.Cyan("Package \"%s\" is installed but not in the lockfile", filepath.Base(dir))
+		}
+	}
+
+	listDiff(oldCmds)
+	updateLockfile()
+
+	return nil
+}
+
+func syncPackage(dir string, pkg lockedPackage, binary binaryInstall) error {
+	status := getSpinner(fmt.Sprintf("Attempting to sync \"%s\" package...", pkg.Name), fmt.Sprintf("Attempting to sync \"%s\" package...", pkg.Name)+"... ["+color.GreenString("OK")+"]\n")
+	status.Start()
+
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		status.FinalMSG = fmt.Sprintf("Attempting to sync \"%s\" package...", pkg.Name) + "... [" + color.RedString("FAIL") + "]\n"
+		status.Stop()
+		return cli.NewExitError(color.RedString("Unable to open package repository: %s", err.Error()), 1)
+	}
+
+	hash := plumbing.NewHash(pkg.Commit)
+	if head, err := repo.Head(); err == nil && head.Hash() == hash {
+		status.FinalMSG = fmt.Sprintf("Attempting to sync \"%s\" package...", pkg.Name) + "... [" + color.CyanString("OK") + "]\n"
+		status.Stop()
+		return nil
+	}
+
+	if _, err := repo.CommitObject(hash); err != nil {
+		if err := fetchRemote(repo); err != nil {
+			status.FinalMSG = fmt.Sprintf("Attempting to sync \"%s\" package...", pkg.Name) + "... [" + color.RedString("FAIL") + "]\n"
+			status.Stop()
+			return cli.NewExitError("Unable to fetch updates", 1)
+		}
+	}
+
+	status.Stop()
+
+	if !installCommit(dir, hash, binary) {
+		return cli.NewExitError(color.RedString("Unable to install %s of \"%s\"", pkg.Commit, pkg.Name), 1)
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+func cmdOutdated(c *cli.Context) error {
+	srcPath, err := getAkamaiCliSrcPath()
+	if err != nil {
+		return err
+	}
+
+	dirs, _ := filepath.Glob(filepath.Join(srcPath, "*"))
+
+	status := getSpinner("Checking for package updates...", "Checking for package updates...... ["+color.GreenString("OK")+"]\n")
+	status.Start()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	rows := [][]string{}
+	outdated := 0
+	for _, dir := range dirs {
+		repo, err := git.PlainOpen(dir)
+		if err != nil {
+			continue
+		}
+
+		row, isOutdated := checkOutdated(dir, repo)
+		if isOutdated {
+			outdated++
+		}
+		rows = append(rows, row)
+	}
+
+	status.Stop()
+
+	if len(rows) == 0 {
+		color.Cyan("No packages installed.")
+		return nil
+	}
+
+	fmt.Fprintln(w, "Package\tCurrent\tLatest\tStatus")
+	for _, row := range rows {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", row[0], row[1], row[2], row[3])
+	}
+	w.Flush()
+
+	if outdated > 0 {
+		fmt.Printf("\n%d package(s) can be updated. Run \"%s\" to update.\n", outdated, color.BlueString("%s update", self()))
+	}
+
+	return nil
+}
+
+// checkOutdated fetches the package remote and compares the current commit and version with the
+// update target, returning a row for the outdated table and whether the package can be updated
+func checkOutdated(dir string, repo *git.Repository) ([]string, bool) {
+	name := filepath.Base(dir)
+
+	head, err := repo.Head()
+	if err != nil {
+		return []string{name, "unknown", "unknown", color.RedString(err.Error())}, false
+	}
+
+	current := getPackageVersion(dir)
+	currentDesc := fmt.Sprintf("%s (%s)", current, head.Hash().String()[:7])
+
+	if err := fetchRemote(repo); err != nil {
+		return []string{name, currentDesc, "unknown", color.RedString("Unable to fetch updates")}, false
+	}
+
+	hash, err := resolveUpdateTarget(repo, getPackageState(dir))
+	if err != nil {
+		return []string{name, currentDesc, "unknown", color.RedString(err.Error())}, false
+	}
+
+	latest := getPackageVersionAt(repo, hash)
+	latestDesc := fmt.Sprintf("%s (%s)", latest, hash.String()[:7])
+
+	if hash == head.Hash() {
+		return []string{name, currentDesc, latestDesc, color.GreenString("up-to-date")}, false
+	}
+
+	return []string{name, currentDesc, latestDesc, color.YellowString("outdated")}, true
+}
+
+func getPackageVersion(dir string) string {
+	cmdPackage, err := readPackage(dir)
+	if err != nil || len(cmdPackage.Commands) == 0 || cmdPackage.Commands[0].Version == "" {
+		return "unknown"
+	}
+
+	return cmdPackage.Commands[0].Version
+}
+
+// getPackageVersionAt reads the package version from cli.json at the given commit
+func getPackageVersionAt(repo *git.Repository, hash plumbing.Hash) string {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return "unknown"
+	}
+
+	file, err := commit.File("cli.json")
+	if err != nil {
+		return "unknown"
+	}
+
+	contents, err := file.Contents()
+	if err != nil {
+		return "unknown"
+	}
+
+	var cmdPackage commandPackage
+	if err := json.Unmarshal([]byte(contents), &cmdPackage); err != nil || len(cmdPackage.Commands) == 0 || cmdPackage.Commands[0].Version == "" {
+		return "unknown"
+	}
+
+	return cmdPackage.Commands[0].Version
+}
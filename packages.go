@@ -0,0 +1,178 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+const (
+	installMethodSource = "source"
+	installMethodBinary = "binary"
+
+	maxPackageHistory = 10
+)
+
+// binaryInstall controls when a package's binary is installed instead of installing it from source
+type binaryInstall int
+
+const (
+	// binaryIfConfirmed offers to install the binary if installing from source fails
+	binaryIfConfirmed binaryInstall = iota
+	// binaryIfSourceFails installs the binary without asking if installing from source fails, see --force
+	binaryIfSourceFails
+	// binaryOnly installs the binary without trying to install from source
+	binaryOnly
+)
+
+// getBinaryInstall returns when to install binaries, given whether --force was used
+func getBinaryInstall(forceBinary bool) binaryInstall {
+	if forceBinary {
+		return binaryIfSourceFails
+	}
+
+	return binaryIfConfirmed
+}
+
+type packageState struct {
+	Repo     string
+	Ref      string
+	Strategy string
+	Method   string
+	History  []string
+	Link     string
+}
+
+func getPackagesFilePath() (string, error) {
+	cliPath, err := getAkamaiCliPath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(cliPath, "packages"), nil
+}
+
+// getPackageName returns the name of the package in dir, used for its config section, e.g. "property" for cli-property
+func getPackageName(dir string) string {
+	return strings.TrimPrefix(filepath.Base(dir), "cli-")
+}
+
+func getPackageState(dir string) packageState {
+	path, err := getPackagesFilePath()
+	if err != nil {
+		return packageState{}
+	}
+
+	packages, err := openConfigFile(path)
+	if err != nil {
+		return packageState{}
+	}
+
+	section := packages.Section(filepath.Base(dir))
+	return packageState{
+		Repo:     section.Key("repo").String(),
+		Ref:      section.Key("ref").String(),
+		Strategy: section.Key("update-strategy").String(),
+		Method:   section.Key("install-method").String(),
+		History:  section.Key("history").Strings(","),
+		Link:     section.Key("link").String(),
+	}
+}
+
+func setPackageState(dir string, state packageState) error {
+	path, err := getPackagesFilePath()
+	if err != nil {
+		return err
+	}
+
+	unlock, err := lockConfigFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	packages, err := reloadConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	section := packages.Section(filepath.Base(dir))
+	section.Key("repo").SetValue(state.Repo)
+	section.Key("ref").SetValue(state.Ref)
+	section.Key("update-strategy").SetValue(state.Strategy)
+	section.Key("install-method").SetValue(state.Method)
+	section.Key("history").SetValue(strings.Join(state.History, ","))
+	section.Key("link").SetValue(state.Link)
+
+	return saveConfigFile(path)
+}
+
+func setPackageMethod(dir string, method string) error {
+	state := getPackageState(dir)
+	state.Method = method
+
+	return setPackageState(dir, state)
+}
+
+// pushHistory records a commit the package can be rolled back to
+func (state *packageState) pushHistory(commit string) {
+	state.History = append(state.History, commit)
+	if len(state.History) > maxPackageHistory {
+		state.History = state.History[len(state.History)-maxPackageHistory:]
+	}
+}
+
+// popHistory removes and returns the most recent commit the package can be rolled back to
+func (state *packageState) popHistory() string {
+	if len(state.History) == 0 {
+		return ""
+	}
+
+	commit := state.History[len(state.History)-1]
+	state.History = state.History[:len(state.History)-1]
+
+	return commit
+}
+
+func removePackageState(dir string) error {
+	path, err := getPackagesFilePath()
+	if err != nil {
+		return err
+	}
+
+	unlock, err := lockConfigFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	packages, err := reloadConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	packages.DeleteSection(filepath.Base(dir))
+
+	return saveConfigFile(path)
+}
+
+// getLinkedPackageDirs returns the local directories registered using "akamai install --link"
+func getLinkedPackageDirs() []string {
+	path, err := getPackagesFilePath()
+	if err != nil {
+		return nil
+	}
+
+	packages, err := openConfigFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var dirs []string
+	for _, section := range packages.Sections() {
+		if link := section.Key("link").String(); link != "" {
+			dirs = append(dirs, link)
+		}
+	}
+
+	return dirs
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/kardianos/osext"
+	"github.com/mattn/go-isatty"
+	"github.com/urfave/cli"
+)
+
+const (
+	defaultJobs       = 4
+	parallelWorkerEnv = "AKAMAI_CLI_PARALLEL_WORKER"
+)
+
+type jobResult struct {
+	name     string
+	err      error
+	duration time.Duration
+}
+
+// getJobs returns the maximum number of packages to install or update at the same time
+func getJobs(c *cli.Context) int {
+	if jobs := c.Int("jobs"); jobs > 0 {
+		return jobs
+	}
+
+	if jobs, err := strconv.Atoi(getConfigValue("cli", "jobs")); err == nil && jobs > 0 {
+		return jobs
+	}
+
+	return defaultJobs
+}
+
+// confirmParallelBinaryFallback asks once, before packages are installed in parallel, whether to install the
+// binary of packages that cannot be installed from source, as workers cannot ask for each package
+func confirmParallelBinaryFallback() bool {
+	if !isatty.IsTerminal(os.Stdout.Fd()) && !isatty.IsCygwinTerminal(os.Stdout.Fd()) {
+		return false
+	}
+
+	fmt.Print("If a package cannot be installed from source, would you like to try to download and install its binary? (Y/n): ")
+	answer := ""
+	fmt.Scanln(&answer)
+
+	return answer == "" || strings.ToLower(answer) == "y"
+}
+
+func isParallelWorker() bool {
+	return os.Getenv(parallelWorkerEnv) != ""
+}
+
+func setupParallelWorker() {
+	if os.Getenv(parallelWorkerEnv) == "color" {
+		color.NoColor = false
+	}
+}
+
+// runParallel runs "akamai <args> <target>" for each target, with at most jobs running at the same time.
+// Each package is handled by a separate process, as installing packages changes the environment.
+func runParallel(jobs int, args []string, targets []string) error {
+	results := runParallelJobs(jobs, args, targets, os.Stdout)
+	return summarizeJobs(os.Stdout, results)
+}
+
+// runParallelJobs runs the jobs, writing the output of each job to w once it has finished
+func runParallelJobs(jobs int, args []string, targets []string, w io.Writer) []jobResult {
+	selfPath, err := osext.Executable()
+	if err != nil {
+		selfPath = os.Args[0]
+	}
+
+	worker := "1"
+	if !color.NoColor {
+		worker = "color"
+	}
+
+	results := make([]jobResult, len(targets))
+	queue := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	var outputLock sync.Mutex
+
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target string) {
+			defer wg.Done()
+			queue <- struct{}{}
+			defer func() { <-queue }()
+
+			output := &bytes.Buffer{}
+			start := time.Now()
+
+			cmd := exec.Command(selfPath, append(append([]string{}, args...), target)...)
+			cmd.Env = append(os.Environ(), parallelWorkerEnv+"="+worker)
+			cmd.Stdout = output
+			cmd.Stderr = output
+			err := cmd.Run()
+
+			results[i] = jobResult{name: target, err: err, duration: time.Since(start)}
+
+			outputLock.Lock()
+			defer outputLock.Unlock()
+			fmt.Fprintln(w, color.YellowString("\n==> %s", target))
+			w.Write(output.Bytes())
+		}(i, target)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// summarizeJobs writes a table of the results to w, and returns an error if any job failed
+func summarizeJobs(w io.Writer, results []jobResult) error {
+	failed := 0
+	fmt.Fprintln(w, color.YellowString("\nSummary:\n"))
+	table := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(table, "  Package\tResult\tTime")
+	for _, result := range results {
+		status := color.GreenString("OK")
+		if result.err != nil {
+			status = color.RedString("FAIL")
+			failed++
+		}
+		fmt.Fprintf(table, "  %s\t%s\t%s\n", result.name, status, result.duration.Round(time.Millisecond*100))
+	}
+	table.Flush()
+
+	if failed > 0 {
+		return cli.NewExitError(color.RedString("\n%d of %d package(s) failed", failed, len(results)), 1)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	profileEnv           = "AKAMAI_PROFILE"
+	profileSectionPrefix = "profile."
+)
+
+// getProfile returns the name of the active profile, set using --profile or $AKAMAI_PROFILE
+func getProfile() string {
+	return os.Getenv(profileEnv)
+}
+
+// setProfile makes the named profile active for this process and any commands it runs
+func setProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+
+	config, err := openGlobalConfig()
+	if err != nil {
+		return err
+	}
+
+	if _, err := config.GetSection(profileSectionPrefix + name); err != nil {
+		return fmt.Errorf("profile \"%s\" not found, use \"%s config set %s%s.<key> <value>\" to create it", name, self(), profileSectionPrefix, name)
+	}
+
+	os.Setenv(profileEnv, name)
+	layeredConfig = nil
+
+	return nil
+}
+
+// getProfileConfig returns the package settings set by the active profile, by section and key. Package settings
+// are stored in the profile section as <package>.<key>.
+func getProfileConfig() map[string]map[string]string {
+	name := getProfile()
+	if name == "" {
+		return nil
+	}
+
+	config, err := openGlobalConfig()
+	if err != nil {
+		return nil
+	}
+
+	section, err := config.GetSection(profileSectionPrefix + name)
+	if err != nil {
+		return nil
+	}
+
+	overrides := make(map[string]map[string]string)
+	for _, key := range section.Keys() {
+		parts := strings.SplitN(key.Name(), ".", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		if overrides[parts[0]] == nil {
+			overrides[parts[0]] = make(map[string]string)
+		}
+		overrides[parts[0]][parts[1]] = key.String()
+	}
+
+	return overrides
+}
+
+// getProfileCredentials returns the credential settings of the active profile, see credentialKeys
+func getProfileCredentials() map[string]string {
+	credentials := make(map[string]string)
+
+	name := getProfile()
+	if name == "" {
+		return credentials
+	}
+
+	config, err := openGlobalConfig()
+	if err != nil {
+		return credentials
+	}
+
+	section, err := config.GetSection(profileSectionPrefix + name)
+	if err != nil {
+		return credentials
+	}
+
+	for key := range credentialKeys {
+		if value := section.Key(key).String(); value != "" {
+			credentials[key] = value
+		}
+	}
+
+	return credentials
+}
+
+// validateProfileValue validates a setting in a profile section
+func validateProfileValue(profile string, key string, value string) error {
+	if _, ok := credentialKeys[key]; ok {
+		if value == "" {
+			return fmt.Errorf("invalid value for \"%s.%s\": must not be empty", profile, key)
+		}
+		return nil
+	}
+
+	parts := strings.SplitN(key, ".", 2)
+	if len(parts) != 2 || parts[0] == "cli" {
+		return fmt.Errorf("unknown key \"%s.%s\", must be one of: edgerc, section, account-key, or <package>.<key>", profile, key)
+	}
+
+	return validatePackageSetting(parts[0], parts[1], value)
+}
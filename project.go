@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+const (
+	projectManifestName = ".akamai-cli.json"
+)
+
+// projectManifest lists the packages and settings a project needs, see findProjectManifest
+type projectManifest struct {
+	Packages []string                          `json:"packages"`
+	Config   map[string]map[string]interface{} `json:"config"`
+}
+
+var (
+	projectManifestPath   string
+	projectManifestCache  *projectManifest
+	projectManifestLoaded bool
+)
+
+// findProjectManifest looks for a project manifest in the current directory and each of its parents
+func findProjectManifest() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+
+	for {
+		path := filepath.Join(dir, projectManifestName)
+		if stat, err := os.Stat(path); err == nil && !stat.IsDir() {
+			return path
+		}
+
+		if filepath.Dir(dir) == dir {
+			return ""
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// getProjectManifest returns the project manifest for the current directory, or nil if there is none
+func getProjectManifest() (*projectManifest, string, error) {
+	if projectManifestLoaded {
+		return projectManifestCache, projectManifestPath, nil
+	}
+
+	path := findProjectManifest()
+	if path == "" {
+		projectManifestLoaded = true
+		return nil, "", nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, path, err
+	}
+
+	var manifest projectManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, path, err
+	}
+
+	projectManifestPath = path
+	projectManifestCache = &manifest
+	projectManifestLoaded = true
+
+	return projectManifestCache, projectManifestPath, nil
+}
+
+// getProjectConfig returns the package settings set in the project manifest, by section and key. Any project can
+// set these, so [cli] and profile settings are ignored, see getIgnoredProjectConfig.
+func getProjectConfig() map[string]map[string]string {
+	manifest, _, err := getProjectManifest()
+	if err != nil || manifest == nil {
+		return nil
+	}
+
+	overrides := make(map[string]map[string]string)
+	for section, values := range manifest.Config {
+		if !isProjectConfigSection(section) {
+			continue
+		}
+
+		overrides[section] = make(map[string]string)
+		for key, value := range values {
+			overrides[section][key] = formatJSONValue(value)
+		}
+	}
+
+	return overrides
+}
+
+// getIgnoredProjectConfig returns the settings in the project manifest that are not package settings, as section.key
+func getIgnoredProjectConfig() []string {
+	manifest, _, err := getProjectManifest()
+	if err != nil || manifest == nil {
+		return nil
+	}
+
+	var ignored []string
+	for section, values := range manifest.Config {
+		if isProjectConfigSection(section) {
+			continue
+		}
+
+		for key := range values {
+			ignored = append(ignored, section+"."+key)
+		}
+	}
+	sort.Strings(ignored)
+
+	return ignored
+}
+
+// isProjectConfigSection returns whether a project manifest may set values in the section, which must belong to a package
+func isProjectConfigSection(section string) bool {
+	return section != "cli" && !strings.HasPrefix(section, profileSectionPrefix)
+}
+
+// installFromProject installs the packages listed in the project manifest that are not installed yet, and
+// moves installed packages to the ref the manifest pins them to
+func installFromProject(forceBinary bool) error {
+	manifest, path, err := getProjectManifest()
+	if err != nil {
+		return cli.NewExitError(color.RedString("Unable to read %s: %s", path, err.Error()), 1)
+	}
+
+	if manifest == nil {
+		return cli.NewExitError(color.RedString("You must specify a repository URL, or run this command in a project with a %s file", projectManifestName), 1)
+	}
+
+	fmt.Printf("Installing packages from %s\n", color.BlueString(path))
+	if ignored := getIgnoredProjectConfig(); len(ignored) > 0 {
+		color.Cyan("Ignoring %s in %s, only package settings can be set by a project", strings.Join(ignored, ", "), path)
+	}
+
+	srcPath, err := getAkamaiCliSrcPath()
+	if err != nil {
+		return err
+	}
+
+	oldCmds := getCommands()
+
+	for _, pkg := range manifest.Packages {
+		repo, ref := splitRepoRef(pkg)
+		repo = githubize(repo)
+		packageDir := filepath.Join(srcPath, strings.TrimSuffix(filepath.Base(repo), ".git"))
+
+		if _, err := os.Stat(packageDir); os.IsNotExist(err) {
+			if _, err := installRepo(repo, ref, getBinaryInstall(forceBinary)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		state := getPackageState(packageDir)
+		if ref == "" || ref == state.Ref {
+			fmt.Printf("Package \"%s\" is already installed... [%s]\n", filepath.Base(packageDir), color.CyanString("OK"))
+			continue
+		}
+
+		if err := checkoutPackageRef(packageDir, ref, forceBinary); err != nil {
+			return err
+		}
+	}
+
+	listDiff(oldCmds)
+	updateLockfile()
+
+	return nil
+}
+
+// checkoutPackageRef moves an installed package to the given ref, and pins it to it
+func checkoutPackageRef(packageDir string, ref string, forceBinary bool) error {
+	name := filepath.Base(packageDir)
+	status := getSpinner(fmt.Sprintf("Attempting to checkout \"%s\" of \"%s\" package...", ref, name), fmt.Sprintf("Attempting to checkout \"%s\" of \"%s\" package...", ref, name)+"... ["+color.GreenString("OK")+"]\n")
+	status.Start()
+
+	repo, err := git.PlainOpen(packageDir)
+	if err != nil {
+		status.FinalMSG = fmt.Sprintf("Attempting to checkout \"%s\" of \"%s\" package...", ref, name) + "... [" + color.RedString("FAIL") + "]\n"
+		status.Stop()
+		return cli.NewExitError(color.RedString("Unable to open package repository: %s", err.Error()), 1)
+	}
+
+	if err := fetchRemote(repo); err != nil {
+		status.FinalMSG = fmt.Sprintf("Attempting to checkout \"%s\" of \"%s\" package...", ref, name) + "... [" + color.RedString("FAIL") + "]\n"
+		status.Stop()
+		return cli.NewExitError("Unable to fetch updates", 1)
+	}
+
+	hash, err := resolveRef(repo, ref)
+	if err != nil {
+		status.FinalMSG = fmt.Sprintf("Attempting to checkout \"%s\" of \"%s\" package...", ref, name) + "... [" + color.RedString("FAIL") + "]\n"
+		status.Stop()
+		return cli.NewExitError(color.RedString("Unable to checkout \"%s\": %s", ref, err.Error()), 1)
+	}
+
+	status.Stop()
+
+	head, err := repo.Head()
+	if err != nil || head.Hash() != hash {
+		if !installCommit(packageDir, hash, getBinaryInstall(forceBinary)) {
+			return cli.NewExitError(color.RedString("Unable to install \"%s\" of \"%s\"", ref, name), 1)
+		}
+	}
+
+	state := getPackageState(packageDir)
+	if head != nil && head.Hash() != hash {
+		state.pushHistory(head.Hash().String())
+	}
+	state.Ref = ref
+	setPackageState(packageDir, state)
+
+	return nil
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+const (
+	defaultReleaseURL = "https://github.com/akamai/cli/releases"
+
+	upgradeChannelStable = "stable"
+	upgradeChannelBeta   = "beta"
+	upgradeChannelPinned = "pinned:"
+)
+
+// getReleaseURL returns the base URL releases are downloaded from. Mirrors must use the same
+// layout as GitHub releases, see fetchLatestVersion and fetchReleaseVersions.
+func getReleaseURL() string {
+	if url := getConfigValue("cli", "release-url"); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+
+	return defaultReleaseURL
+}
+
+func getUpgradeChannel() string {
+	if channel := getConfigValue("cli", "upgrade-channel"); channel != "" {
+		return channel
+	}
+
+	return upgradeChannelStable
+}
+
+func validateUpgradeChannel(channel string) error {
+	switch {
+	case channel == upgradeChannelStable, channel == upgradeChannelBeta:
+		return nil
+	case strings.HasPrefix(channel, upgradeChannelPinned) && len(channel) > len(upgradeChannelPinned):
+		return nil
+	}
+
+	return fmt.Errorf("unknown upgrade channel \"%s\", must be one of: %s, %s, %s<version>", channel, upgradeChannelStable, upgradeChannelBeta, upgradeChannelPinned)
+}
+
+// getChannelVersion returns the version the channel should be running
+func getChannelVersion(releaseURL string, channel string) (string, error) {
+	switch {
+	case channel == upgradeChannelStable:
+		return fetchLatestVersion(releaseURL)
+	case channel == upgradeChannelBeta:
+		versions, err := fetchReleaseVersions(releaseURL)
+		if err != nil {
+			return "", err
+		}
+
+		latest := ""
+		for _, version := range versions {
+			if latest == "" || versionCompare(latest, version) == 1 {
+				latest = version
+			}
+		}
+
+		if latest == "" {
+			return "", fmt.Errorf("no releases found")
+		}
+
+		return latest, nil
+	case strings.HasPrefix(channel, upgradeChannelPinned) && len(channel) > len(upgradeChannelPinned):
+		return strings.TrimPrefix(channel, upgradeChannelPinned), nil
+	}
+
+	return "", validateUpgradeChannel(channel)
+}
+
+// isUpgradeAvailable reports whether the channel version should replace the current version. Only
+// pinned channels will move to an older version.
+func isUpgradeAvailable(channel string, current string, version string) bool {
+	if strings.HasPrefix(channel, upgradeChannelPinned) {
+		return version != current
+	}
+
+	return versionCompare(current, version) == 1
+}
+
+// fetchLatestVersion uses <releaseURL>/latest, which either redirects to the latest release (as on
+// GitHub), or returns the latest version as plain text
+func fetchLatestVersion(releaseURL string) (string, error) {
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Get(releaseURL + "/latest")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case 301, 302, 303, 307, 308:
+		return path.Base(resp.Header.Get("Location")), nil
+	case 200:
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return "", err
+		}
+
+		if version := strings.TrimSpace(string(body)); version != "" && !strings.ContainsAny(version, " <\n") {
+			return version, nil
+		}
+	}
+
+	return "", fmt.Errorf("unable to determine latest release: %s", resp.Status)
+}
+
+type releaseFeed struct {
+	Entries []struct {
+		Title   string `xml:"title"`
+		Content string `xml:"content"`
+		Links   []struct {
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+	} `xml:"entry"`
+}
+
+type release struct {
+	Version string `json:"version"`
+	Title   string `json:"title"`
+	Notes   string `json:"notes"`
+}
+
+// fetchReleases returns all releases, including pre-releases, listed in the <releaseURL>.atom feed
+func fetchReleases(releaseURL string) ([]release, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(releaseURL + ".atom")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("unable to fetch releases: %s", resp.Status)
+	}
+
+	var feed releaseFeed
+	if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		return nil, err
+	}
+
+	var releases []release
+	for _, entry := range feed.Entries {
+		for _, link := range entry.Links {
+			if strings.Contains(link.Href, "/releases/tag/") {
+				releases = append(releases, release{
+					Version: path.Base(link.Href),
+					Title:   strings.TrimSpace(entry.Title),
+					Notes:   htmlToText(entry.Content),
+				})
+				break
+			}
+		}
+	}
+
+	return releases, nil
+}
+
+func fetchReleaseVersions(releaseURL string) ([]string, error) {
+	releases, err := fetchReleases(releaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	for _, release := range releases {
+		versions = append(versions, release.Version)
+	}
+
+	return versions, nil
+}
+
+// getReleaseNotes returns the releases after current, up to and including latest, newest first.
+// Pre-releases are only included when upgrading to a pre-release.
+func getReleaseNotes(releaseURL string, current string, latest string) ([]release, error) {
+	releases, err := fetchReleases(releaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	_, latestPrerelease := splitPrerelease(latest)
+
+	var notes []release
+	for _, release := range releases {
+		if _, prerelease := splitPrerelease(release.Version); prerelease != "" && latestPrerelease == "" {
+			continue
+		}
+
+		if versionCompare(current, release.Version) == 1 && versionCompare(release.Version, latest) != -1 {
+			notes = append(notes, release)
+		}
+	}
+
+	sort.SliceStable(notes, func(i, j int) bool {
+		return versionCompare(notes[i].Version, notes[j].Version) == -1
+	})
+
+	return notes, nil
+}
+
+func printReleaseNotes(releaseURL string, current string, latest string) error {
+	notes, err := getReleaseNotes(releaseURL, current, latest)
+	if err != nil {
+		return err
+	}
+
+	if len(notes) == 0 {
+		fmt.Println("No release notes found.")
+		return nil
+	}
+
+	printReleases(notes)
+
+	return nil
+}
+
+func printReleases(notes []release) {
+
+	bold := color.New(color.FgWhite, color.Bold)
+	for _, release := range notes {
+		bold.Printf("\nv%s", strings.TrimPrefix(release.Version, "v"))
+		if release.Title != "" && release.Title != release.Version {
+			fmt.Printf(" - %s", release.Title)
+		}
+		fmt.Println()
+
+		for _, line := range strings.Split(release.Notes, "\n") {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+	fmt.Println()
+}
+
+var (
+	htmlListItems = regexp.MustCompile(`(?i)<li[^>]*>`)
+	htmlBreaks    = regexp.MustCompile(`(?i)<br\s*/?>|</p>|</li>|</h[1-6]>|</ul>|</ol>`)
+	htmlTags      = regexp.MustCompile(`<[^>]*>`)
+	blankLines    = regexp.MustCompile(`\n\s*\n+`)
+)
+
+// htmlToText converts release notes rendered as HTML into plain text for the terminal
+func htmlToText(content string) string {
+	content = htmlListItems.ReplaceAllString(content, "- ")
+	content = htmlBreaks.ReplaceAllString(content, "\n")
+	content = htmlTags.ReplaceAllString(content, "")
+	content = html.UnescapeString(content)
+
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		lines = append(lines, strings.TrimSpace(line))
+	}
+
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n"))
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+func cmdRollback(c *cli.Context) error {
+	if !c.Args().Present() {
+		return cli.NewExitError(color.RedString("You must specify a command"), 1)
+	}
+
+	for _, cmd := range c.Args() {
+		if err := rollbackPackage(cmd); err != nil {
+			return err
+		}
+	}
+
+	updateLockfile()
+
+	return nil
+}
+
+func rollbackPackage(cmd string) error {
+	exec, err := findExec(cmd)
+	if err != nil {
+		return cli.NewExitError(color.RedString("Command \"%s\" not found. Try \"%s help\".\n", cmd, self()), 1)
+	}
+
+	status := getSpinner(fmt.Sprintf("Attempting to roll back \"%s\" command...", cmd), fmt.Sprintf("Attempting to roll back \"%s\" command...", cmd)+"... ["+color.GreenString("OK")+"]\n")
+	status.Start()
+
+	repoDir := findPackageDir(filepath.Dir(exec[len(exec)-1]))
+	if repoDir == "" {
+		status.FinalMSG = fmt.Sprintf("Attempting to roll back \"%s\" command...", cmd) + "... [" + color.RedString("FAIL") + "]\n"
+		status.Stop()
+		return cli.NewExitError(color.RedString("unable to roll back, was it installed using "+color.CyanString("\"akamai install\"")+"?"), 1)
+	}
+
+	state := getPackageState(repoDir)
+	if state.Link == repoDir {
+		status.FinalMSG = fmt.Sprintf("Attempting to roll back \"%s\" command...", cmd) + "... [" + color.RedString("FAIL") + "]\n"
+		status.Stop()
+		return cli.NewExitError(color.RedString("command \"%s\" is linked to %s, and cannot be rolled back", cmd, repoDir), 1)
+	}
+
+	previous := state.popHistory()
+	if previous == "" {
+		status.FinalMSG = fmt.Sprintf("Attempting to roll back \"%s\" command...", cmd) + "... [" + color.RedString("FAIL") + "]\n"
+		status.Stop()
+		return cli.NewExitError(color.RedString("No previous version of \"%s\" to roll back to", cmd), 1)
+	}
+
+	repo, err := git.PlainOpen(repoDir)
+	if err != nil {
+		status.FinalMSG = fmt.Sprintf("Attempting to roll back \"%s\" command...", cmd) + "... [" + color.RedString("FAIL") + "]\n"
+		status.Stop()
+		return cli.NewExitError(color.RedString("Unable to open package repository: %s", err.Error()), 1)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		status.FinalMSG = fmt.Sprintf("Attempting to roll back \"%s\" command...", cmd) + "... [" + color.RedString("FAIL") + "]\n"
+		status.Stop()
+		return cli.NewExitError(color.RedString("Unable to determine current version: %s", err.Error()), 1)
+	}
+
+	hash := plumbing.NewHash(previous)
+
+	status.Stop()
+	fmt.Printf("Rolling back \"%s\" from %s to %s\n", cmd, color.BlueString(describeCommit(repo, head.Hash())), color.BlueString(describeCommit(repo, hash)))
+
+	binary := binaryIfConfirmed
+	if state.Method == installMethodBinary {
+		binary = binaryOnly
+	}
+
+	if !installCommit(repoDir, hash, binary) {
+		return cli.NewExitError(color.RedString("Unable to roll back command, \"%s\" has been left at %s", cmd, describeCommit(repo, head.Hash())), 1)
+	}
+
+	state.Method = getPackageState(repoDir).Method
+	setPackageState(repoDir, state)
+
+	color.Cyan("Note: \"%s update\" will update \"%s\" again. To stay on this version, reinstall it using \"%s install <package>@<ref>\"", self(), cmd, self())
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli"
+)
+
+const (
+	defaultPackageIndex = "https://developer.akamai.com/cli/package-list.json"
+)
+
+type packageIndex struct {
+	Packages []indexedPackage `json:"packages"`
+}
+
+type indexedPackage struct {
+	Name        string    `json:"name"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Version     string    `json:"version"`
+	URL         string    `json:"url"`
+	Commands    []Command `json:"commands"`
+}
+
+func getPackageIndexLocation() string {
+	if location := getConfigValue("cli", "package-index"); location != "" {
+		return location
+	}
+
+	return defaultPackageIndex
+}
+
+func fetchPackageIndex(location string) (packageIndex, error) {
+	var index packageIndex
+	var data []byte
+	var err error
+
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		client := &http.Client{Timeout: 10 * time.Second}
+		resp, err := client.Get(location)
+		if err != nil {
+			return index, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != 200 {
+			return index, fmt.Errorf("unexpected response: %s", resp.Status)
+		}
+
+		data, err = ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return index, err
+		}
+	} else {
+		data, err = ioutil.ReadFile(strings.TrimPrefix(location, "file://"))
+		if err != nil {
+			return index, err
+		}
+	}
+
+	err = json.Unmarshal(data, &index)
+	return index, err
+}
+
+func (pkg indexedPackage) matches(term string) bool {
+	term = strings.ToLower(term)
+
+	haystack := []string{pkg.Name, pkg.Title, pkg.Description, pkg.URL}
+	for _, cmd := range pkg.Commands {
+		haystack = append(haystack, cmd.Name, cmd.Description)
+		haystack = append(haystack, cmd.Aliases...)
+	}
+
+	for _, value := range haystack {
+		if strings.Contains(strings.ToLower(value), term) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func cmdSearch(c *cli.Context) error {
+	status := getSpinner("Fetching package index...", "Fetching package index...... ["+color.GreenString("OK")+"]\n")
+	status.Start()
+
+	index, err := fetchPackageIndex(getPackageIndexLocation())
+	if err != nil {
+		status.FinalMSG = "Fetching package index...... [" + color.RedString("FAIL") + "]\n"
+		status.Stop()
+		return cli.NewExitError(color.RedString("Unable to fetch package index: %s", err.Error()), 1)
+	}
+	status.Stop()
+
+	var results []indexedPackage
+	for _, pkg := range index.Packages {
+		found := true
+		for _, term := range c.Args() {
+			if !pkg.matches(term) {
+				found = false
+				break
+			}
+		}
+
+		if found {
+			results = append(results, pkg)
+		}
+	}
+
+	if len(results) == 0 {
+		color.Cyan("No packages found.")
+		return nil
+	}
+
+	bold := color.New(color.FgWhite, color.Bold)
+
+	color.Yellow("\nPackages Found:\n\n")
+	for _, pkg := range results {
+		bold.Printf("  %s", pkg.Name)
+		if pkg.Version != "" {
+			fmt.Printf(" [v%s]", strings.TrimPrefix(pkg.Version, "v"))
+		}
+		fmt.Println()
+
+		if pkg.Title != "" {
+			fmt.Printf("    %s\n", pkg.Title)
+		}
+		if pkg.Description != "" {
+			fmt.Printf("    %s\n", pkg.Description)
+		}
+		for _, cmd := range pkg.Commands {
+			fmt.Printf("    Command: %s", cmd.Name)
+			if cmd.Description != "" {
+				fmt.Printf(" - %s", cmd.Description)
+			}
+			fmt.Println()
+		}
+		if pkg.URL != "" {
+			fmt.Printf("    Repository: %s\n", pkg.URL)
+			fmt.Printf("    Install: %s\n", color.BlueString("%s install %s", self(), shortenRepo(pkg.URL)))
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+// shortenRepo is the inverse of githubize
+func shortenRepo(repo string) string {
+	short := strings.TrimSuffix(strings.TrimSuffix(repo, "/"), ".git")
+	if !strings.HasPrefix(short, "https://github.com/") {
+		return repo
+	}
+
+	short = strings.TrimPrefix(short, "https://github.com/")
+	if strings.Count(short, "/") != 1 {
+		return repo
+	}
+
+	if strings.HasPrefix(short, "akamai/cli-") {
+		return strings.TrimPrefix(short, "akamai/cli-")
+	}
+
+	return short
+}
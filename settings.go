@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	settingTypeString = "string"
+	settingTypeInt    = "int"
+	settingTypeBool   = "bool"
+)
+
+// packageSetting is a setting declared in cli.json, which users can set using "akamai config set <package>.<name>"
+type packageSetting struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	Default     interface{} `json:"default"`
+	Description string      `json:"description"`
+}
+
+func (setting packageSetting) getType() string {
+	if setting.Type == "" {
+		return settingTypeString
+	}
+
+	return setting.Type
+}
+
+func (setting packageSetting) defaultValue() string {
+	return formatJSONValue(setting.Default)
+}
+
+// formatJSONValue formats a decoded JSON value as a config value
+func formatJSONValue(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case bool:
+		return strconv.FormatBool(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+
+	return fmt.Sprint(value)
+}
+
+func (setting packageSetting) validate(value string) error {
+	switch setting.getType() {
+	case settingTypeString:
+		return nil
+	case settingTypeInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("must be a number")
+		}
+		return nil
+	case settingTypeBool:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("must be one of: true, false")
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown setting type \"%s\"", setting.Type)
+}
+
+// findPackageDirByName returns the directory of the installed package with the given name, see getPackageName
+func findPackageDirByName(name string) string {
+	for _, dir := range filepath.SplitList(getPackagePaths()) {
+		if getPackageName(dir) == name {
+			return dir
+		}
+	}
+
+	return ""
+}
+
+// validatePackageSetting validates settings for packages that declare them. Settings for packages that
+// are not installed, or that do not declare any settings, are not validated.
+func validatePackageSetting(packageName string, key string, value string) error {
+	dir := findPackageDirByName(packageName)
+	if dir == "" {
+		return nil
+	}
+
+	cmdPackage, err := readPackage(dir)
+	if err != nil || len(cmdPackage.Settings) == 0 {
+		return nil
+	}
+
+	var names []string
+	for _, setting := range cmdPackage.Settings {
+		if setting.Name == key {
+			if err := setting.validate(value); err != nil {
+				return fmt.Errorf("invalid value for \"%s.%s\": %s", packageName, key, err.Error())
+			}
+			return nil
+		}
+		names = append(names, setting.Name)
+	}
+
+	return fmt.Errorf("unknown key \"%s.%s\", package \"%s\" has the settings: %s", packageName, key, packageName, strings.Join(names, ", "))
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/ed25519"
+)
+
+const (
+	signatureSuffix = ".minisig"
+)
+
+type trustedKey struct {
+	id  []byte
+	key ed25519.PublicKey
+}
+
+// getTrustedKeys returns the public keys listed in the trusted-keys setting of the [cli] config section
+func getTrustedKeys() ([]trustedKey, error) {
+	var keys []trustedKey
+	for _, value := range strings.Split(getConfigValue("cli", "trusted-keys"), ",") {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+
+		key, err := parsePublicKey(value)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func isSignatureRequired() bool {
+	return getConfigValue("cli", "require-signatures") == "true"
+}
+
+// parsePublicKey accepts a base64 encoded ed25519 public key, or a minisign public key
+func parsePublicKey(value string) (trustedKey, error) {
+	value = strings.TrimSpace(value)
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return trustedKey{}, fmt.Errorf("invalid public key %s: %s", value, err.Error())
+	}
+
+	switch {
+	case len(data) == ed25519.PublicKeySize:
+		return trustedKey{key: ed25519.PublicKey(data)}, nil
+	case len(data) == 10+ed25519.PublicKeySize && string(data[:2]) == "Ed":
+		return trustedKey{id: data[2:10], key: ed25519.PublicKey(data[10:])}, nil
+	}
+
+	return trustedKey{}, fmt.Errorf("invalid public key %s: not an ed25519 key", value)
+}
+
+// verifySignature checks a base64 encoded ed25519 signature, or a minisign signature file, against
+// the trusted keys. Only the signature over the file is checked; the trusted comment is ignored.
+func verifySignature(message []byte, signature []byte, keys []trustedKey) error {
+	var sig []byte
+	for _, line := range strings.Split(string(signature), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "untrusted comment:") {
+			continue
+		}
+
+		var err error
+		if sig, err = base64.StdEncoding.DecodeString(line); err != nil {
+			return fmt.Errorf("invalid signature: %s", err.Error())
+		}
+		break
+	}
+
+	var keyID []byte
+	switch {
+	case len(sig) == ed25519.SignatureSize:
+	case len(sig) == 10+ed25519.SignatureSize && string(sig[:2]) == "Ed":
+		keyID, sig = sig[2:10], sig[10:]
+	case len(sig) == 10+ed25519.SignatureSize && string(sig[:2]) == "ED":
+		keyID, sig = sig[2:10], sig[10:]
+		hash := blake2b.Sum512(message)
+		message = hash[:]
+	default:
+		return fmt.Errorf("invalid signature: not an ed25519 signature")
+	}
+
+	for _, key := range keys {
+		if keyID != nil && key.id != nil && !bytes.Equal(keyID, key.id) {
+			continue
+		}
+
+		if ed25519.Verify(key.key, message, sig) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("signature is not valid for any trusted key")
+}
+
+// verifyDownload checks the signature published at sigURL for a downloaded file. Unsigned files,
+// and files that cannot be verified because no keys are trusted, are only refused in strict mode.
+func verifyDownload(message []byte, sigURL string) error {
+	keys, err := getTrustedKeys()
+	if err != nil {
+		return err
+	}
+
+	strict := isSignatureRequired()
+
+	var signature []byte
+	if sigURL != "" {
+		if signature, err = fetchSignature(sigURL); err != nil {
+			return err
+		}
+	}
+
+	if signature == nil {
+		if strict {
+			return fmt.Errorf("no signature found, and unsigned downloads are not allowed")
+		}
+		// A signature URL is declared, so a missing signature may mean it has been removed to skip verification
+		if sigURL != "" && len(keys) > 0 {
+			return fmt.Errorf("no signature found at %s, and trusted keys are configured", sigURL)
+		}
+		return nil
+	}
+
+	if len(keys) == 0 {
+		if strict {
+			return fmt.Errorf("unable to verify signature, no trusted keys are configured")
+		}
+		return nil
+	}
+
+	return verifySignature(message, signature, keys)
+}
+
+// fetchSignature returns nil if no signature has been published
+func fetchSignature(url string) ([]byte, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	res, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return nil, nil
+	}
+
+	if res.StatusCode != 200 {
+		return nil, fmt.Errorf("unable to fetch signature from %s: %s", url, res.Status)
+	}
+
+	return ioutil.ReadAll(res.Body)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+func getAkamaiCliStagingPath() (string, error) {
+	cliHome, err := getAkamaiCliPath()
+	if err != nil {
+		return "", err
+	}
+
+	stagingPath := filepath.Join(cliHome, "staging")
+	err = os.MkdirAll(stagingPath, 0775)
+	if err != nil {
+		return "", err
+	}
+
+	return stagingPath, nil
+}
+
+// newStagingDir returns an empty directory to stage the package in. The directory has the same
+// name as the package so that it can be installed as-is, and is on the same filesystem as the
+// package source path so that it can be moved into place atomically.
+func newStagingDir(name string) (string, error) {
+	stagingPath, err := getAkamaiCliStagingPath()
+	if err != nil {
+		return "", err
+	}
+
+	removeStaleStagingDirs(stagingPath)
+
+	tmpDir, err := ioutil.TempDir(stagingPath, name+"-")
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(tmpDir, name), nil
+}
+
+// removeStaleStagingDirs cleans up after interrupted installs
+func removeStaleStagingDirs(stagingPath string) {
+	dirs, _ := filepath.Glob(filepath.Join(stagingPath, "*"))
+	for _, dir := range dirs {
+		if stat, err := os.Stat(dir); err == nil && stat.ModTime().Add(time.Hour*24).Before(time.Now()) {
+			os.RemoveAll(dir)
+		}
+	}
+}
+
+func discardStagingDir(stagingDir string) {
+	os.RemoveAll(filepath.Dir(stagingDir))
+}
+
+// commitStagingDir moves the staged package into place, replacing the existing package if there is one
+func commitStagingDir(stagingDir string, packageDir string) error {
+	if _, err := os.Stat(packageDir); os.IsNotExist(err) {
+		if err := os.Rename(stagingDir, packageDir); err != nil {
+			return err
+		}
+
+		discardStagingDir(stagingDir)
+		return nil
+	}
+
+	backupDir := filepath.Join(filepath.Dir(stagingDir), "previous")
+	if err := os.Rename(packageDir, backupDir); err != nil {
+		return err
+	}
+
+	if err := os.Rename(stagingDir, packageDir); err != nil {
+		os.Rename(backupDir, packageDir)
+		return err
+	}
+
+	discardStagingDir(stagingDir)
+	return nil
+}
+
+// installCommit installs the given commit of a package in a staging directory, and only
+// replaces the existing package once it has been installed successfully
+func installCommit(packageDir string, hash plumbing.Hash, binary binaryInstall) bool {
+	stagingDir, err := newStagingDir(filepath.Base(packageDir))
+	if err != nil {
+		return false
+	}
+
+	if err := copyDir(packageDir, stagingDir); err != nil {
+		discardStagingDir(stagingDir)
+		return false
+	}
+
+	repo, err := git.PlainOpen(stagingDir)
+	if err != nil {
+		discardStagingDir(stagingDir)
+		return false
+	}
+
+	if err := checkoutHash(repo, hash); err != nil {
+		discardStagingDir(stagingDir)
+		return false
+	}
+
+	if !installPackage(stagingDir, binary) {
+		discardStagingDir(stagingDir)
+		return false
+	}
+
+	if err := commitStagingDir(stagingDir, packageDir); err != nil {
+		discardStagingDir(stagingDir)
+		return false
+	}
+
+	return true
+}
+
+func copyDir(src string, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+func copyFile(src string, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
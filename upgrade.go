@@ -5,144 +5,305 @@ package main
 import (
 	"bytes"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
-	"path"
-	"runtime"
+	"os/exec"
+	"path/filepath"
 	"strings"
-	"text/template"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/inconshreveable/go-update"
 	"github.com/kardianos/osext"
 	"github.com/mattn/go-isatty"
+	"github.com/urfave/cli"
+)
+
+const (
+	upgradeCheckEnv     = "AKAMAI_CLI_UPGRADE_CHECK"
+	upgradeCheckTimeout = 5 * time.Second
 )
 
 func checkForUpgrade(force bool) string {
+	data := strings.TrimSpace(getConfigValue("cli", "last-upgrade-check"))
+
 	if !isatty.IsTerminal(os.Stdout.Fd()) && !isatty.IsCygwinTerminal(os.Stdout.Fd()) {
+		if !force && data != "ignore" && isUpgradeNoticeEnabled() {
+			showUpgradeNotice(data)
+		}
 		return ""
 	}
 
-	data := strings.TrimSpace(getConfigValue("cli", "last-upgrade-check"))
-
 	if data == "ignore" {
 		return ""
 	}
 
-	checkForUpgrade := false
-	if data == "never" || force {
-		checkForUpgrade = true
+	if force {
+		setConfigValue("cli", "last-upgrade-check", time.Now().Format(time.RFC3339))
+		err := saveConfig()
+		if err != nil {
+			return ""
+		}
+
+		latestVersion := getLatestReleaseVersion()
+		if latestVersion != "0" && isUpgradeAvailable(getUpgradeChannel(), VERSION, latestVersion) {
+			return latestVersion
+		}
+
+		return ""
 	}
 
-	if !checkForUpgrade {
-		configValue := strings.TrimPrefix(strings.TrimSuffix(string(data),"\""), "\"")
-		lastUpgrade, err := time.Parse(time.RFC3339, configValue)
+	latestVersion := getCheckedUpgrade(data)
+	if latestVersion == "" {
+		return ""
+	}
 
-		if err != nil {
-			return ""
+	if notes := readCachedReleaseNotes(); len(notes) > 0 {
+		printReleases(notes)
+	}
+
+	fmt.Printf(
+		"New upgrade found: %s (you are running: %s). Upgrade now? [Y/n]: ",
+		color.BlueString(latestVersion),
+		color.BlueString(VERSION),
+	)
+	answer := ""
+	fmt.Scanln(&answer)
+	if answer != "" && strings.ToLower(answer) != "y" {
+		return ""
+	}
+
+	return latestVersion
+}
+
+// getCheckedUpgrade starts a background check if one is due, and returns the version found by the last check if
+// it is an upgrade. The result of a check is only returned once, so that users are notified at most once per check.
+func getCheckedUpgrade(lastCheck string) string {
+	if isUpgradeCheckDue(lastCheck) {
+		due := false
+		err := updateConfig(func() {
+			// Another process may have started a check since the config was read
+			if due = isUpgradeCheckDue(getConfigValue("cli", "last-upgrade-check")); due {
+				setConfigValue("cli", "last-upgrade-check", time.Now().Format(time.RFC3339))
+			}
+		})
+		if err == nil && due {
+			startUpgradeCheck()
 		}
+	}
 
-		currentTime := time.Now()
-		if lastUpgrade.Add(time.Hour * 24).Before(currentTime) {
-			checkForUpgrade = true
+	latestVersion := getConfigValue("cli", "latest-version")
+	if latestVersion == "" || !isUpgradeAvailable(getUpgradeChannel(), VERSION, latestVersion) {
+		return ""
+	}
+
+	err := updateConfig(func() {
+		// Keep the result of a check that finished after the config was read, so that it is shown next time
+		if getConfigValue("cli", "latest-version") == latestVersion {
+			setConfigValue("cli", "latest-version", "")
 		}
+	})
+	if err != nil {
+		return ""
 	}
 
-	if checkForUpgrade {
-		setConfigValue("cli","last-upgrade-check", time.Now().Format(time.RFC3339))
-		err := saveConfig()
+	return latestVersion
+}
+
+// isUpgradeNoticeEnabled reports whether to notify about upgrades when not running in a terminal. It is enabled
+// using upgrade-notice in the [cli] config section, or the AKAMAI_CLI_UPGRADE_NOTICE environment variable.
+func isUpgradeNoticeEnabled() bool {
+	if value := os.Getenv("AKAMAI_CLI_UPGRADE_NOTICE"); value != "" {
+		return value == "1" || value == "true"
+	}
+
+	return getConfigValue("cli", "upgrade-notice") == "true"
+}
+
+// showUpgradeNotice prints to stderr, so that it does not interfere with the output of commands
+func showUpgradeNotice(lastCheck string) {
+	// Upgrade checks are never enabled by firstRun when not running in a terminal
+	if lastCheck == "" {
+		lastCheck = "never"
+	}
+
+	if latestVersion := getCheckedUpgrade(lastCheck); latestVersion != "" {
+		fmt.Fprintf(os.Stderr, "A new version of Akamai CLI is available: v%s (you are running: v%s). Run \"%s upgrade\" to upgrade.\n", latestVersion, VERSION, self())
+	}
+}
+
+func isUpgradeCheckDue(lastCheck string) bool {
+	if lastCheck == "never" {
+		return true
+	}
+
+	lastUpgrade, err := time.Parse(time.RFC3339, strings.TrimPrefix(strings.TrimSuffix(lastCheck, "\""), "\""))
+	if err != nil {
+		return false
+	}
+
+	return lastUpgrade.Add(time.Hour * 24).Before(time.Now())
+}
+
+// startUpgradeCheck checks for upgrades in a separate process, so that commands never wait on the network.
+// The result is stored in the config, and used the next time Akamai CLI is run.
+func startUpgradeCheck() {
+	selfPath, err := osext.Executable()
+	if err != nil {
+		return
+	}
+
+	cmd := exec.Command(selfPath)
+	cmd.Env = append(os.Environ(), upgradeCheckEnv+"=1")
+	detachProcess(cmd)
+	if err := cmd.Start(); err != nil {
+		return
+	}
+
+	cmd.Process.Release()
+}
+
+func isUpgradeCheckWorker() bool {
+	return os.Getenv(upgradeCheckEnv) != ""
+}
+
+// runUpgradeCheck is run by the process started by startUpgradeCheck
+func runUpgradeCheck() {
+	type result struct {
+		version string
+		notes   []release
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		latestVersion, err := getChannelVersion(getReleaseURL(), getUpgradeChannel())
 		if err != nil {
-			return ""
+			close(done)
+			return
 		}
 
-		latestVersion := getLatestReleaseVersion()
-		if versionCompare(VERSION, latestVersion) == 1 {
-			if !force {
-				fmt.Printf(
-					"New upgrade found: %s (you are running: %s). Upgrade now? [Y/n]: ",
-					color.BlueString(latestVersion),
-					color.BlueString(VERSION),
-				)
-				answer := ""
-				fmt.Scanln(&answer)
-				if answer != "" && strings.ToLower(answer) != "y" {
-					return ""
-				}
-			}
-			return latestVersion
+		notes, _ := getReleaseNotes(getReleaseURL(), VERSION, latestVersion)
+		done <- result{version: latestVersion, notes: notes}
+	}()
+
+	var latest result
+	select {
+	case r, ok := <-done:
+		if !ok {
+			return
 		}
+		latest = r
+	case <-time.After(upgradeCheckTimeout):
+		return
 	}
 
-	return ""
+	writeCachedReleaseNotes(latest.notes)
+
+	updateConfig(func() {
+		setConfigValue("cli", "latest-version", latest.version)
+	})
 }
 
-func getLatestReleaseVersion() string {
-	client := &http.Client{
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
+func getReleaseNotesCachePath() (string, error) {
+	cachePath, err := getAkamaiCliCachePath()
+	if err != nil {
+		return "", err
 	}
-	resp, err := client.Head("https://github.com/akamai/cli/releases/latest")
+
+	return filepath.Join(cachePath, "release-notes.json"), nil
+}
+
+func readCachedReleaseNotes() []release {
+	path, err := getReleaseNotesCachePath()
 	if err != nil {
-		return "0"
+		return nil
 	}
 
-	if resp.StatusCode != 302 {
-		return "0"
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
 	}
 
-	location := resp.Header.Get("Location")
-	latestVersion := path.Base(location)
+	var notes []release
+	if err := json.Unmarshal(data, &notes); err != nil {
+		return nil
+	}
 
-	return latestVersion
+	return notes
 }
 
-func upgradeCli(latestVersion string) bool {
-	status := getSpinner("Upgrading Akamai CLI", "Upgrading Akamai CLI...... ["+color.GreenString("OK")+"]\n\n")
-
-	cmd := Command{
-		Version: latestVersion,
-		Bin:     "https://github.com/akamai/cli/releases/download/{{.Version}}/akamai-{{.Version}}-{{.OS}}{{.Arch}}{{.BinSuffix}}",
-		Arch:    runtime.GOARCH,
-		OS:      runtime.GOOS,
+func writeCachedReleaseNotes(notes []release) {
+	path, err := getReleaseNotesCachePath()
+	if err != nil {
+		return
 	}
 
-	if runtime.GOOS == "darwin" {
-		cmd.OS = "mac"
+	data, err := json.Marshal(notes)
+	if err != nil {
+		return
 	}
 
-	if runtime.GOOS == "windows" {
-		cmd.BinSuffix = ".exe"
+	ioutil.WriteFile(path, data, 0644)
+}
+
+func getLatestReleaseVersion() string {
+	latestVersion, err := getChannelVersion(getReleaseURL(), getUpgradeChannel())
+	if err != nil {
+		return "0"
 	}
 
-	t := template.Must(template.New("url").Parse(cmd.Bin))
-	buf := &bytes.Buffer{}
-	if err := t.Execute(buf, cmd); err != nil {
+	return latestVersion
+}
+
+func upgradeCli(latestVersion string) bool {
+	status := getSpinner("Upgrading Akamai CLI", "Upgrading Akamai CLI...... ["+color.GreenString("OK")+"]\n\n")
+
+	cmd := getBinCommand(Command{
+		Version: latestVersion,
+		Bin:     getReleaseURL() + "/download/{{.Version}}/akamai-{{.Version}}-{{.OS}}{{.Arch}}{{.BinSuffix}}",
+	})
+
+	url, err := renderBinTemplate(cmd.Bin, cmd)
+	if err != nil {
 		return false
 	}
 
-	url := buf.String()
 	status.Start()
 	resp, err := http.Get(url)
-	defer resp.Body.Close()
 	if err != nil || resp.StatusCode != 200 {
 		status.FinalMSG = status.Prefix + "...... [" + color.RedString("FAIL") + "]\n"
 		status.Stop()
 		color.Red("Unable to download release, please try again.")
 		return false
 	}
+	defer resp.Body.Close()
+
+	release, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		status.FinalMSG = status.Prefix + "...... [" + color.RedString("FAIL") + "]\n"
+		status.Stop()
+		color.Red("Unable to download release, please try again.")
+		return false
+	}
+
+	if err := verifyDownload(release, url+signatureSuffix); err != nil {
+		status.FinalMSG = status.Prefix + "...... [" + color.RedString("FAIL") + "]\n"
+		status.Stop()
+		color.Red("Unable to verify release signature: %s", err.Error())
+		return false
+	}
 
 	shaResp, err := http.Get(url + ".sig")
-	defer shaResp.Body.Close()
 	if err != nil || shaResp.StatusCode != 200 {
 		status.FinalMSG = status.Prefix + "...... [" + color.RedString("FAIL") + "]\n"
 		status.Stop()
 		color.Red("Unable to retrieve signature for verification, please try again.")
 		return false
 	}
+	defer shaResp.Body.Close()
 
 	shabody, err := ioutil.ReadAll(shaResp.Body)
 	if err != nil {
@@ -168,7 +329,11 @@ func upgradeCli(latestVersion string) bool {
 		return false
 	}
 
-	err = update.Apply(resp.Body, update.Options{TargetPath: selfPath, Checksum: shasum})
+	if err := saveVersion(selfPath, VERSION); err != nil {
+		color.Cyan("Unable to keep a copy of the current version: %s", err.Error())
+	}
+
+	err = update.Apply(bytes.NewReader(release), update.Options{TargetPath: selfPath, Checksum: shasum})
 	if err != nil {
 		status.FinalMSG = status.Prefix + "...... [" + color.RedString("FAIL") + "]\n"
 		status.Stop()
@@ -197,12 +362,83 @@ func upgradeCli(latestVersion string) bool {
 	return true
 }
 
+func rollbackCli() bool {
+	previousPath, previousVersion := getPreviousVersion()
+	if previousPath == "" {
+		color.Red("No previous version found, previous versions are kept in %s", color.BlueString("$HOME/.akamai-cli/versions"))
+		return false
+	}
+
+	status := getSpinner(fmt.Sprintf("Rolling back Akamai CLI to v%s", previousVersion), fmt.Sprintf("Rolling back Akamai CLI to v%s", previousVersion)+"...... ["+color.GreenString("OK")+"]\n")
+	status.Start()
+
+	selfPath, err := osext.Executable()
+	if err != nil {
+		status.FinalMSG = status.Prefix + "...... [" + color.RedString("FAIL") + "]\n"
+		status.Stop()
+		color.Red("Unable to determine install location")
+		return false
+	}
+
+	previous, err := os.Open(previousPath)
+	if err != nil {
+		status.FinalMSG = status.Prefix + "...... [" + color.RedString("FAIL") + "]\n"
+		status.Stop()
+		color.Red("Unable to read previous version: %s", err.Error())
+		return false
+	}
+	defer previous.Close()
+
+	// The current version is not kept, so that rolling back again restores the version before the previous one,
+	// instead of switching back to this one
+	err = update.Apply(previous, update.Options{TargetPath: selfPath})
+	if err != nil {
+		status.FinalMSG = status.Prefix + "...... [" + color.RedString("FAIL") + "]\n"
+		status.Stop()
+		if rerr := update.RollbackError(err); rerr != nil {
+			color.Red("Unable to install or rollback, please re-install.")
+			os.Exit(1)
+		}
+		color.Red("Unable to roll back: %s", err.Error())
+		return false
+	}
+
+	previous.Close()
+	os.Remove(previousPath)
+
+	status.Stop()
+
+	return true
+}
+
 func getUpgradeCommand() *commandPackage {
 	return &commandPackage{
 		Commands: []Command{
 			{
 				Name:        "upgrade",
 				Description: "Upgrade Akamai CLI to the latest version",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "channel",
+						Usage: "Switch to a release channel: stable, beta, or pinned:<version>",
+					},
+					cli.StringFlag{
+						Name:  "to",
+						Usage: "Install a specific version, including older versions",
+					},
+					cli.BoolFlag{
+						Name:  "check",
+						Usage: "Check for a new version without upgrading. Prints the result as JSON, and exits with status 2 if an upgrade is available",
+					},
+					cli.BoolFlag{
+						Name:  "notes",
+						Usage: "Show the release notes for versions newer than the current version, without upgrading",
+					},
+					cli.BoolFlag{
+						Name:  "rollback",
+						Usage: "Restore the version that was installed before the last upgrade",
+					},
+				},
 			},
 		},
 		action: cmdUpgrade,
@@ -6,6 +6,13 @@ func checkForUpgrade(force bool) string {
 	return ""
 }
 
+func isUpgradeCheckWorker() bool {
+	return false
+}
+
+func runUpgradeCheck() {
+}
+
 func getLatestReleaseVersion() string {
 	return "0"
 }
@@ -14,6 +21,10 @@ func upgradeCli(latestVersion string) bool {
 	return false
 }
 
+func rollbackCli() bool {
+	return false
+}
+
 func getUpgradeCommand() *commandPackage {
 	return nil
 }
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultKeepVersions = 3
+)
+
+func getAkamaiCliVersionsPath() (string, error) {
+	cliHome, err := getAkamaiCliPath()
+	if err != nil {
+		return "", err
+	}
+
+	versionsPath := filepath.Join(cliHome, "versions")
+	err = os.MkdirAll(versionsPath, 0775)
+	if err != nil {
+		return "", err
+	}
+
+	return versionsPath, nil
+}
+
+// getKeepVersions returns how many previous versions of Akamai CLI to keep, set using keep-versions in the [cli] config section
+func getKeepVersions() int {
+	if keep, err := strconv.Atoi(getConfigValue("cli", "keep-versions")); err == nil && keep >= 0 {
+		return keep
+	}
+
+	return defaultKeepVersions
+}
+
+func getVersionBinName(version string) string {
+	if runtime.GOOS == "windows" {
+		return "akamai-" + version + ".exe"
+	}
+
+	return "akamai-" + version
+}
+
+// saveVersion keeps a copy of the Akamai CLI executable at selfPath, which is running the given version
+func saveVersion(selfPath string, version string) error {
+	keep := getKeepVersions()
+	if keep > 0 {
+		versionsPath, err := getAkamaiCliVersionsPath()
+		if err != nil {
+			return err
+		}
+
+		if err := copyFile(selfPath, filepath.Join(versionsPath, getVersionBinName(version)), 0755); err != nil {
+			return err
+		}
+	}
+
+	return pruneVersions(keep)
+}
+
+// getSavedVersions returns the saved executables, most recently saved first
+func getSavedVersions() ([]string, error) {
+	versionsPath, err := getAkamaiCliVersionsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(versionsPath, "akamai-*"))
+	if err != nil {
+		return nil, err
+	}
+
+	modTimes := make(map[string]int64)
+	for _, file := range files {
+		if stat, err := os.Stat(file); err == nil {
+			modTimes[file] = stat.ModTime().UnixNano()
+		}
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		return modTimes[files[i]] > modTimes[files[j]]
+	})
+
+	return files, nil
+}
+
+func pruneVersions(keep int) error {
+	files, err := getSavedVersions()
+	if err != nil {
+		return err
+	}
+
+	for i, file := range files {
+		if i >= keep {
+			if err := os.Remove(file); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// getPreviousVersion returns the path and version of the most recently saved executable that is not the current version
+func getPreviousVersion() (string, string) {
+	files, err := getSavedVersions()
+	if err != nil {
+		return "", ""
+	}
+
+	for _, file := range files {
+		version := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), "akamai-"), ".exe")
+		if version != VERSION {
+			return file, version
+		}
+	}
+
+	return "", ""
+}